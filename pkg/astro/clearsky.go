@@ -0,0 +1,97 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// SolarConstant is the mean extraterrestrial irradiance at one astronomical
+// unit in W/m²
+const SolarConstant = 1367.0
+
+// Irradiance holds the components of the solar irradiance in W/m²
+type Irradiance struct {
+	GHI float64 // Global horizontal irradiance
+	DNI float64 // Direct normal irradiance
+	DHI float64 // Diffuse horizontal irradiance
+}
+
+// ExtraterrestrialIrradiance returns the irradiance normal to the sun at the
+// top of the atmosphere on the day of t in W/m², following Spencer (1971).
+func ExtraterrestrialIrradiance(t time.Time) float64 {
+	b := 2 * math.Pi * float64(t.UTC().YearDay()-1) / 365
+	return SolarConstant * (1.00011 + 0.034221*math.Cos(b) + 0.00128*math.Sin(b) + 0.000719*math.Cos(2*b) + 0.000077*math.Sin(2*b))
+}
+
+// RelativeAirmass returns the relative optical airmass for the apparent
+// zenith angle in degrees after Kasten and Young (1989), or 0 with the sun
+// below the horizon.
+func RelativeAirmass(zenith float64) float64 {
+	if zenith >= 90 {
+		return 0
+	}
+	return 1 / (math.Cos(zenith*deg) + 0.50572*math.Pow(96.07995-zenith, -1.6364))
+}
+
+// ClearSkyIneichenPerez returns the clear-sky irradiance of the Ineichen and Perez
+// (2002) model for the apparent zenith angle in degrees, the Linke turbidity
+// at airmass 2 and the elevation in meters. dniExtra is the extraterrestrial
+// irradiance. This is the formulation of pvlib.
+func ClearSkyIneichenPerez(zenith float64, linkeTurbidity float64, elevation float64, dniExtra float64) Irradiance {
+	cosZenith := math.Cos(zenith * deg)
+	if zenith >= 90 || cosZenith <= 0 {
+		return Irradiance{}
+	}
+
+	// Airmass corrected for the pressure at the elevation
+	airmass := RelativeAirmass(zenith) * altitudePressure(elevation) / 101325
+	tl := linkeTurbidity
+
+	fh1 := math.Exp(-elevation / 8000)
+	fh2 := math.Exp(-elevation / 1250)
+	cg1 := 5.09e-5*elevation + 0.868
+	cg2 := 3.92e-5*elevation + 0.0387
+
+	ghi := cg1 * dniExtra * cosZenith * math.Max(math.Exp(-cg2*airmass*(fh1+fh2*(tl-1))), 0)
+
+	b := 0.664 + 0.163/fh1
+	bnci := dniExtra * math.Max(b*math.Exp(-0.09*airmass*(tl-1)), 0)
+	// The direct irradiance can't exceed the global one
+	bnci2 := ghi * math.Max((1-(0.1-0.2*math.Exp(-tl))/(0.1+0.882/fh1))/cosZenith, 0)
+	dni := math.Min(bnci, bnci2)
+
+	return Irradiance{GHI: ghi, DNI: dni, DHI: ghi - dni*cosZenith}
+}
+
+// ClearSkyHaurwitz returns the clear-sky irradiance of the Haurwitz (1945)
+// model for the apparent zenith angle in degrees. The model only gives the
+// global irradiance, the Erbs model splits it into direct and diffuse.
+func ClearSkyHaurwitz(zenith float64, dniExtra float64) Irradiance {
+	cosZenith := math.Cos(zenith * deg)
+	if zenith >= 90 || cosZenith <= 0 {
+		return Irradiance{}
+	}
+
+	ghi := 1098 * cosZenith * math.Exp(-0.059/cosZenith)
+	dhi := ghi * erbsDiffuseFraction(ghi/(dniExtra*cosZenith))
+	return Irradiance{GHI: ghi, DNI: (ghi - dhi) / cosZenith, DHI: dhi}
+}
+
+// erbsDiffuseFraction returns the share of the diffuse irradiance in the
+// global irradiance for the clearness index kt (Erbs et al., 1982)
+func erbsDiffuseFraction(kt float64) float64 {
+	switch {
+	case kt <= 0.22:
+		return 1 - 0.09*kt
+	case kt <= 0.8:
+		return 0.9511 - 0.1604*kt + 4.388*kt*kt - 16.638*math.Pow(kt, 3) + 12.336*math.Pow(kt, 4)
+	default:
+		return 0.165
+	}
+}
+
+// altitudePressure returns the air pressure in Pa of the standard
+// atmosphere at the elevation in meters
+func altitudePressure(elevation float64) float64 {
+	return 100 * math.Pow((44331.514-elevation)/11880.516, 1/0.1902632)
+}
@@ -0,0 +1,53 @@
+package astro_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraterrestrialIrradiance(t *testing.T) {
+	// The earth is closest to the sun in early January
+	assert.InDelta(t, 1414, astro.ExtraterrestrialIrradiance(time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)), 2)
+	assert.InDelta(t, 1322, astro.ExtraterrestrialIrradiance(time.Date(2024, 7, 5, 12, 0, 0, 0, time.UTC)), 2)
+}
+
+func TestRelativeAirmass(t *testing.T) {
+	assert.InDelta(t, 1.0, astro.RelativeAirmass(0), 0.001)
+	assert.InDelta(t, 2.0, astro.RelativeAirmass(60), 0.01)
+	assert.InDelta(t, 37.92, astro.RelativeAirmass(90-0.0001), 0.01)
+	assert.Equal(t, 0.0, astro.RelativeAirmass(95))
+}
+
+func TestClearSkyIneichenPerez(t *testing.T) {
+	for _, zenith := range []float64{0, 30, 60, 80, 89} {
+		irradiance := astro.ClearSkyIneichenPerez(zenith, 3, 0, astro.SolarConstant)
+
+		// The components add up to the global irradiance
+		assert.InDelta(t, irradiance.GHI, irradiance.DNI*math.Cos(zenith*math.Pi/180)+irradiance.DHI, 1e-9)
+		assert.Greater(t, irradiance.DHI, 0.0)
+	}
+
+	// Sun in the zenith on a clear day
+	zenith := astro.ClearSkyIneichenPerez(0, 3, 0, astro.SolarConstant)
+	assert.InDelta(t, 1056.6, zenith.GHI, 0.5)
+	assert.InDelta(t, 944.3, zenith.DNI, 0.5)
+
+	// Haze dims the direct irradiance, altitude brightens the sky
+	assert.Less(t, astro.ClearSkyIneichenPerez(30, 5, 0, astro.SolarConstant).DNI, astro.ClearSkyIneichenPerez(30, 2, 0, astro.SolarConstant).DNI)
+	assert.Greater(t, astro.ClearSkyIneichenPerez(30, 3, 3000, astro.SolarConstant).GHI, astro.ClearSkyIneichenPerez(30, 3, 0, astro.SolarConstant).GHI)
+
+	assert.Equal(t, astro.Irradiance{}, astro.ClearSkyIneichenPerez(90, 3, 0, astro.SolarConstant))
+}
+
+func TestClearSkyHaurwitz(t *testing.T) {
+	zenith := astro.ClearSkyHaurwitz(0, astro.SolarConstant)
+	assert.InDelta(t, 1098*math.Exp(-0.059), zenith.GHI, 1e-9)
+	assert.InDelta(t, zenith.GHI, zenith.DNI+zenith.DHI, 1e-9)
+	assert.Greater(t, zenith.DNI, zenith.DHI)
+
+	assert.Equal(t, astro.Irradiance{}, astro.ClearSkyHaurwitz(100, astro.SolarConstant))
+}
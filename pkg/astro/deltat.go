@@ -0,0 +1,40 @@
+package astro
+
+import "time"
+
+// DeltaT estimates the difference between terrestrial time and universal
+// time in seconds with the polynomials of Espenak and Meeus, valid from 1900
+// until 2150. Outside that range the long-term parabola is used.
+func DeltaT(t time.Time) float64 {
+	y := float64(t.Year()) + (float64(t.YearDay())-0.5)/365.25
+
+	switch {
+	case y < 1900:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	case y < 1920:
+		u := y - 1900
+		return -2.79 + u*(1.494119+u*(-0.0598939+u*(0.0061966+u*-0.000197)))
+	case y < 1941:
+		u := y - 1920
+		return 21.20 + u*(0.84493+u*(-0.076100+u*0.0020936))
+	case y < 1961:
+		u := y - 1950
+		return 29.07 + u*(0.407+u*(-1.0/233+u/2547))
+	case y < 1986:
+		u := y - 1975
+		return 45.45 + u*(1.067+u*(-1.0/260-u/718))
+	case y < 2005:
+		u := y - 2000
+		return 63.86 + u*(0.3345+u*(-0.060374+u*(0.0017275+u*(0.000651814+u*0.00002373599))))
+	case y < 2050:
+		u := y - 2000
+		return 62.92 + u*(0.32217+u*0.005589)
+	case y < 2150:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-y)
+	default:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u
+	}
+}
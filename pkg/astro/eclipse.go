@@ -0,0 +1,282 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Kinds of eclipses
+const (
+	EclipsePenumbral = "penumbral"
+	EclipsePartial   = "partial"
+	EclipseAnnular   = "annular"
+	EclipseTotal     = "total"
+)
+
+// SolarEclipse holds the local circumstances of a solar eclipse. Contacts
+// that don't happen at the location are zero.
+type SolarEclipse struct {
+	Kind string // Partial, annular or total as seen from the observer
+
+	C1      time.Time // Partial eclipse begins
+	C2      time.Time // Annular or total eclipse begins
+	Maximum time.Time
+	C3      time.Time // Annular or total eclipse ends
+	C4      time.Time // Partial eclipse ends
+
+	Magnitude   float64 // Fraction of the sun diameter covered by the moon at the maximum
+	Obscuration float64 // Fraction of the sun disk covered by the moon at the maximum
+
+	// Part of the eclipse with the sun above the horizon, zero if it isn't visible at all
+	VisibleStart time.Time
+	VisibleEnd   time.Time
+}
+
+// LunarEclipse holds the circumstances of a lunar eclipse. The contacts are
+// the same for all observers, only the visible part depends on the location.
+// Contacts that don't happen are zero.
+type LunarEclipse struct {
+	Kind string // Penumbral, partial or total
+
+	P1      time.Time // Moon enters the penumbra
+	U1      time.Time // Moon enters the umbra
+	U2      time.Time // Total eclipse begins
+	Maximum time.Time
+	U3      time.Time // Total eclipse ends
+	U4      time.Time // Moon leaves the umbra
+	P4      time.Time // Moon leaves the penumbra
+
+	Magnitude   float64 // Umbral magnitude, penumbral magnitude for penumbral eclipses
+	Obscuration float64 // Fraction of the moon disk in the umbra at the maximum
+
+	// Part of the eclipse with the moon above the horizon, zero if it isn't visible at all
+	VisibleStart time.Time
+	VisibleEnd   time.Time
+}
+
+// Half of the longest possible eclipse around the syzygy, brackets all contacts
+const eclipseWindow = 6 * time.Hour
+
+// Enlargement of the shadow of the earth by its atmosphere
+const shadowEnlargement = 1.02
+
+// SolarEclipses finds the solar eclipses with their maximum at or after from
+// and before to that have a partial phase at the location of the observer,
+// whether or not the sun is above the horizon.
+func SolarEclipses(from time.Time, to time.Time, obs Observer) []SolarEclipse {
+	eclipses := []SolarEclipse{}
+
+	for _, newMoon := range MoonPhases(NewMoon, from.Add(-eclipseWindow), to.Add(eclipseWindow), obs.DeltaT) {
+		// Far from a node the moon passes above or below the sun for everyone
+		if math.Abs(geocentricMoon(julianDay(newMoon)+obs.DeltaT/86400).Latitude) > 1.6 {
+			continue
+		}
+
+		// Angular distance between the centers and the apparent radii of sun and moon
+		circles := func(t time.Time) (float64, float64, float64) {
+			sun := SolarPositionSPA(t, obs)
+			moon := MoonPositionAt(t, obs)
+			separation := angularDistance(sun.TopocentricRightAscension, sun.TopocentricDeclination, moon.TopocentricRightAscension, moon.TopocentricDeclination)
+			return separation, 959.63 / 3600 / sun.R, moon.SemiDiameter
+		}
+
+		start, end := newMoon.Add(-eclipseWindow), newMoon.Add(eclipseWindow)
+		maximum := goldenSection(start, end, func(t time.Time) float64 {
+			separation, _, _ := circles(t)
+			return separation
+		})
+		separation, sunRadius, moonRadius := circles(maximum)
+		if separation >= sunRadius+moonRadius || maximum.Before(from) || !maximum.Before(to) {
+			continue
+		}
+
+		eclipse := SolarEclipse{
+			Kind:        EclipsePartial,
+			Maximum:     maximum,
+			Magnitude:   (sunRadius + moonRadius - separation) / (2 * sunRadius),
+			Obscuration: overlap(sunRadius, moonRadius, separation) / (math.Pi * sunRadius * sunRadius),
+		}
+
+		partial := func(t time.Time) float64 {
+			separation, sunRadius, moonRadius := circles(t)
+			return separation - (sunRadius + moonRadius)
+		}
+		eclipse.C1 = bisect(start, maximum, partial)
+		eclipse.C4 = bisect(maximum, end, partial)
+
+		if separation < math.Abs(sunRadius-moonRadius) {
+			eclipse.Kind = EclipseAnnular
+			if moonRadius > sunRadius {
+				eclipse.Kind = EclipseTotal
+			}
+			central := func(t time.Time) float64 {
+				separation, sunRadius, moonRadius := circles(t)
+				return separation - math.Abs(sunRadius-moonRadius)
+			}
+			eclipse.C2 = bisect(eclipse.C1, maximum, central)
+			eclipse.C3 = bisect(maximum, eclipse.C4, central)
+		}
+
+		// The sun is up while its upper limb is above the horizon
+		horizon := -(sunRadius + atmosRefract + HorizonDip(obs.Elevation))
+		eclipse.VisibleStart, eclipse.VisibleEnd = visibleSpan(eclipse.C1, eclipse.C4, func(t time.Time) float64 {
+			return SolarPositionSPA(t, obs).TrueElevation - horizon
+		})
+
+		eclipses = append(eclipses, eclipse)
+	}
+
+	return eclipses
+}
+
+// LunarEclipses finds the lunar eclipses with their maximum at or after from
+// and before to, whether or not the moon is above the horizon of the observer.
+func LunarEclipses(from time.Time, to time.Time, obs Observer) []LunarEclipse {
+	eclipses := []LunarEclipse{}
+
+	for _, fullMoon := range MoonPhases(FullMoon, from.Add(-eclipseWindow), to.Add(eclipseWindow), obs.DeltaT) {
+		// Far from a node the moon passes above or below the shadow of the earth
+		if math.Abs(geocentricMoon(julianDay(fullMoon)+obs.DeltaT/86400).Latitude) > 1.7 {
+			continue
+		}
+
+		// Angular distance between the centers of moon and shadow and the radii of moon, umbra and penumbra
+		circles := func(t time.Time) (float64, float64, float64, float64) {
+			jd := julianDay(t)
+			sun := geocentricSun(jd, obs.DeltaT)
+			moon := MoonPositionAt(t, obs)
+			separation := angularDistance(limitDegrees(sun.RightAscension+180), -sun.Declination, moon.RightAscension, moon.Declination)
+
+			sunRadius := 959.63 / 3600 / sun.R
+			sunParallax := 8.794 / 3600 / sun.R
+			radius := math.Asin(moonRadius/moon.Distance) / deg
+			umbra := shadowEnlargement * (0.998340*moon.HorizontalParallax + sunParallax - sunRadius)
+			penumbra := shadowEnlargement * (0.998340*moon.HorizontalParallax + sunParallax + sunRadius)
+			return separation, radius, umbra, penumbra
+		}
+
+		start, end := fullMoon.Add(-eclipseWindow), fullMoon.Add(eclipseWindow)
+		maximum := goldenSection(start, end, func(t time.Time) float64 {
+			separation, _, _, _ := circles(t)
+			return separation
+		})
+		separation, moonRadius, umbra, penumbra := circles(maximum)
+		if separation >= penumbra+moonRadius || maximum.Before(from) || !maximum.Before(to) {
+			continue
+		}
+
+		eclipse := LunarEclipse{
+			Kind:        EclipsePenumbral,
+			Maximum:     maximum,
+			Magnitude:   (penumbra + moonRadius - separation) / (2 * moonRadius),
+			Obscuration: overlap(moonRadius, umbra, separation) / (math.Pi * moonRadius * moonRadius),
+		}
+
+		// Contact with the shadow of the given radius, offset by the moon radius
+		contact := func(radius func(float64, float64) float64) func(time.Time) float64 {
+			return func(t time.Time) float64 {
+				separation, moonRadius, umbra, penumbra := circles(t)
+				return separation - radius(umbra, penumbra) - moonRadius
+			}
+		}
+		penumbral := contact(func(_ float64, penumbra float64) float64 { return penumbra })
+		eclipse.P1 = bisect(start, maximum, penumbral)
+		eclipse.P4 = bisect(maximum, end, penumbral)
+
+		if separation < umbra+moonRadius {
+			eclipse.Kind = EclipsePartial
+			eclipse.Magnitude = (umbra + moonRadius - separation) / (2 * moonRadius)
+			umbral := contact(func(umbra float64, _ float64) float64 { return umbra })
+			eclipse.U1 = bisect(eclipse.P1, maximum, umbral)
+			eclipse.U4 = bisect(maximum, eclipse.P4, umbral)
+		}
+
+		if separation < umbra-moonRadius {
+			eclipse.Kind = EclipseTotal
+			// The far edge of the moon enters the umbra
+			total := func(t time.Time) float64 {
+				separation, moonRadius, umbra, _ := circles(t)
+				return separation - umbra + moonRadius
+			}
+			eclipse.U2 = bisect(eclipse.U1, maximum, total)
+			eclipse.U3 = bisect(maximum, eclipse.U4, total)
+		}
+
+		// The moon is up while its upper limb is above the horizon
+		horizon := -(atmosRefract + HorizonDip(obs.Elevation))
+		eclipse.VisibleStart, eclipse.VisibleEnd = visibleSpan(eclipse.P1, eclipse.P4, func(t time.Time) float64 {
+			moon := MoonPositionAt(t, obs)
+			return moon.TrueElevation + moon.SemiDiameter - horizon
+		})
+
+		eclipses = append(eclipses, eclipse)
+	}
+
+	return eclipses
+}
+
+// visibleSpan returns the first and the last time between start and end at
+// which above is not negative, both zero if it is negative throughout
+func visibleSpan(start time.Time, end time.Time, above func(time.Time) float64) (time.Time, time.Time) {
+	const step = 5 * time.Minute
+
+	var first, last time.Time
+	prev, prevAbove := start, above(start)
+	if prevAbove >= 0 {
+		first = start
+	}
+	for prev.Before(end) {
+		next := prev.Add(step)
+		if next.After(end) {
+			next = end
+		}
+		nextAbove := above(next)
+
+		if prevAbove < 0 && nextAbove >= 0 {
+			rise := bisect(prev, next, above).Add(time.Second)
+			if first.IsZero() {
+				first = rise
+			}
+		}
+		if prevAbove >= 0 && nextAbove < 0 {
+			last = bisect(prev, next, above)
+		}
+		if nextAbove >= 0 {
+			last = next
+		}
+
+		prev, prevAbove = next, nextAbove
+	}
+
+	if first.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+	return first, last
+}
+
+// angularDistance returns the angle between two points given as right
+// ascension and declination, all in degrees
+func angularDistance(ra1 float64, dec1 float64, ra2 float64, dec2 float64) float64 {
+	// The haversine formula stays accurate for the small angles around an eclipse
+	dRA := (ra2 - ra1) * deg
+	dDec := (dec2 - dec1) * deg
+	a := math.Sin(dDec/2)*math.Sin(dDec/2) + math.Cos(dec1*deg)*math.Cos(dec2*deg)*math.Sin(dRA/2)*math.Sin(dRA/2)
+	return 2 * math.Asin(math.Sqrt(math.Min(1, a))) / deg
+}
+
+// overlap returns the area in which two circles with the given radii and
+// distance between their centers overlap
+func overlap(r1 float64, r2 float64, distance float64) float64 {
+	if distance >= r1+r2 {
+		return 0
+	}
+	if distance <= math.Abs(r1-r2) {
+		r := math.Min(r1, r2)
+		return math.Pi * r * r
+	}
+
+	a1 := r1 * r1 * math.Acos(clamp((distance*distance+r1*r1-r2*r2)/(2*distance*r1)))
+	a2 := r2 * r2 * math.Acos(clamp((distance*distance+r2*r2-r1*r1)/(2*distance*r2)))
+	triangle := math.Sqrt((-distance + r1 + r2) * (distance + r1 - r2) * (distance - r1 + r2) * (distance + r1 + r2))
+	return a1 + a2 - triangle/2
+}
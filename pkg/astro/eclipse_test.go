@@ -0,0 +1,83 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolarEclipses(t *testing.T) {
+	// Total solar eclipse of 2024-04-08 seen from Dallas, contacts from NASA
+	obs := astro.Observer{Latitude: 32.7767, Longitude: -96.797, Pressure: astro.StandardPressure, Temperature: astro.StandardTemperature, DeltaT: 69.2}
+	eclipses := astro.SolarEclipses(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), obs)
+	if assert.Len(t, eclipses, 1) {
+		e := eclipses[0]
+		assert.Equal(t, astro.EclipseTotal, e.Kind)
+		assert.WithinDuration(t, time.Date(2024, 4, 8, 17, 23, 0, 0, time.UTC), e.C1, time.Minute)
+		assert.WithinDuration(t, time.Date(2024, 4, 8, 18, 40, 44, 0, time.UTC), e.C2, 30*time.Second)
+		assert.WithinDuration(t, time.Date(2024, 4, 8, 18, 42, 40, 0, time.UTC), e.Maximum, 30*time.Second)
+		assert.WithinDuration(t, time.Date(2024, 4, 8, 18, 44, 35, 0, time.UTC), e.C3, 30*time.Second)
+		assert.WithinDuration(t, time.Date(2024, 4, 8, 20, 2, 48, 0, time.UTC), e.C4, time.Minute)
+		assert.Greater(t, e.Magnitude, 1.0)
+		assert.Equal(t, 1.0, e.Obscuration)
+		assert.Equal(t, e.C1, e.VisibleStart)
+		assert.Equal(t, e.C4, e.VisibleEnd)
+	}
+
+	// The same eclipse is only partial in New York
+	obs.Latitude, obs.Longitude = 40.7128, -74.006
+	eclipses = astro.SolarEclipses(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), obs)
+	if assert.Len(t, eclipses, 1) {
+		e := eclipses[0]
+		assert.Equal(t, astro.EclipsePartial, e.Kind)
+		assert.True(t, e.C2.IsZero())
+		assert.InDelta(t, 0.91, e.Magnitude, 0.01)
+		assert.InDelta(t, 0.898, e.Obscuration, 0.01)
+	}
+
+	// and happens after sunset in Vienna
+	obs.Latitude, obs.Longitude = 48.2082, 16.3738
+	eclipses = astro.SolarEclipses(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), obs)
+	if assert.Len(t, eclipses, 1) {
+		assert.True(t, eclipses[0].VisibleStart.IsZero())
+		assert.True(t, eclipses[0].VisibleEnd.IsZero())
+	}
+}
+
+func TestLunarEclipses(t *testing.T) {
+	// Total lunar eclipse of 2022-11-08, contacts from NASA
+	obs := astro.Observer{Latitude: 40.7128, Longitude: -74.006, Pressure: astro.StandardPressure, Temperature: astro.StandardTemperature, DeltaT: 69.3}
+	eclipses := astro.LunarEclipses(time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC), obs)
+	if assert.Len(t, eclipses, 1) {
+		e := eclipses[0]
+		assert.Equal(t, astro.EclipseTotal, e.Kind)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 8, 2, 15, 0, time.UTC), e.P1, 2*time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 9, 9, 12, 0, time.UTC), e.U1, time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 10, 16, 39, 0, time.UTC), e.U2, time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 10, 59, 11, 0, time.UTC), e.Maximum, time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 11, 41, 38, 0, time.UTC), e.U3, time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 12, 49, 3, 0, time.UTC), e.U4, time.Minute)
+		assert.WithinDuration(t, time.Date(2022, 11, 8, 13, 56, 9, 0, time.UTC), e.P4, 2*time.Minute)
+		assert.InDelta(t, 1.359, e.Magnitude, 0.01)
+		assert.Equal(t, 1.0, e.Obscuration)
+
+		// The moon sets in New York at the end of totality
+		assert.Equal(t, e.P1, e.VisibleStart)
+		assert.True(t, e.VisibleEnd.After(e.U2) && e.VisibleEnd.Before(e.U4))
+	}
+
+	// Partial lunar eclipse of 2023-10-28 seen from Vienna
+	obs.Latitude, obs.Longitude = 48.2082, 16.3738
+	eclipses = astro.LunarEclipses(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC), obs)
+	if assert.Len(t, eclipses, 1) {
+		e := eclipses[0]
+		assert.Equal(t, astro.EclipsePartial, e.Kind)
+		assert.True(t, e.U2.IsZero())
+		assert.WithinDuration(t, time.Date(2023, 10, 28, 20, 14, 0, 0, time.UTC), e.Maximum, time.Minute)
+		assert.InDelta(t, 0.122, e.Magnitude, 0.01)
+		assert.Equal(t, e.P1, e.VisibleStart)
+		assert.Equal(t, e.P4, e.VisibleEnd)
+	}
+}
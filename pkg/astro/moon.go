@@ -0,0 +1,139 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Mean radius of the moon in kilometers
+const moonRadius = 1737.4
+
+// Equatorial radius of the earth in kilometers
+const earthRadius = 6378.14
+
+// MoonPosition holds the apparent position of the moon, angles are in
+// degrees and distances in kilometers.
+type MoonPosition struct {
+	JulianDay float64
+
+	// Geocentric coordinates
+	Longitude          float64 // Apparent ecliptic longitude
+	Latitude           float64 // Ecliptic latitude
+	Distance           float64 // Distance between the centers of earth and moon
+	HorizontalParallax float64
+	RightAscension     float64
+	Declination        float64
+
+	// Topocentric coordinates corrected for the parallax
+	TopocentricRightAscension float64
+	TopocentricDeclination    float64
+	TopocentricDistance       float64
+	SemiDiameter              float64 // Apparent radius of the disk seen from the observer
+
+	HourAngle     float64 // Topocentric local hour angle
+	TrueElevation float64 // Topocentric elevation angle without refraction
+	Elevation     float64 // Topocentric elevation angle corrected for refraction
+	Azimuth       float64 // Topocentric azimuth, eastward from north
+}
+
+// MoonPositionAt computes the position of the moon at t for obs from the
+// periodic terms of Meeus, chapter 47, accurate to about 10 arc seconds
+func MoonPositionAt(t time.Time, obs Observer) MoonPosition {
+	jd := julianDay(t)
+	earth := geocentricSun(jd, obs.DeltaT)
+	moon := geocentricMoon(jd + obs.DeltaT/86400)
+
+	p := MoonPosition{
+		JulianDay: jd,
+		Longitude: limitDegrees(moon.Longitude + earth.DeltaPsi),
+		Latitude:  moon.Latitude,
+		Distance:  moon.Distance,
+	}
+	p.HorizontalParallax = math.Asin(earthRadius/p.Distance) / deg
+
+	lambda := p.Longitude * deg
+	beta := p.Latitude * deg
+	epsilon := earth.Epsilon * deg
+	p.RightAscension = limitDegrees(math.Atan2(math.Sin(lambda)*math.Cos(epsilon)-math.Tan(beta)*math.Sin(epsilon), math.Cos(lambda)) / deg)
+	p.Declination = math.Asin(math.Sin(beta)*math.Cos(epsilon)+math.Cos(beta)*math.Sin(epsilon)*math.Sin(lambda)) / deg
+
+	// Subtract the position of the observer, both in a frame aligned with the local meridian
+	phi := obs.Latitude * deg
+	u := math.Atan(0.99664719 * math.Tan(phi))
+	rhoCos := (math.Cos(u) + obs.Elevation/6378140*math.Cos(phi)) * earthRadius
+	rhoSin := (0.99664719*math.Sin(u) + obs.Elevation/6378140*math.Sin(phi)) * earthRadius
+	h := limitDegrees(earth.siderealTime+obs.Longitude-p.RightAscension) * deg
+	delta := p.Declination * deg
+	x := p.Distance*math.Cos(delta)*math.Cos(h) - rhoCos
+	y := -p.Distance * math.Cos(delta) * math.Sin(h)
+	z := p.Distance*math.Sin(delta) - rhoSin
+
+	p.TopocentricDistance = math.Sqrt(x*x + y*y + z*z)
+	deltaPrime := math.Asin(z / p.TopocentricDistance)
+	hPrime := math.Atan2(-y, x)
+	p.TopocentricDeclination = deltaPrime / deg
+	p.HourAngle = limitDegrees(hPrime / deg)
+	p.TopocentricRightAscension = limitDegrees(earth.siderealTime + obs.Longitude - p.HourAngle)
+	p.SemiDiameter = math.Asin(moonRadius/p.TopocentricDistance) / deg
+
+	p.TrueElevation = math.Asin(math.Sin(phi)*math.Sin(deltaPrime)+math.Cos(phi)*math.Cos(deltaPrime)*math.Cos(hPrime)) / deg
+	p.Elevation = p.TrueElevation + Refraction(p.TrueElevation, obs.Pressure, obs.Temperature)
+	p.Azimuth = limitDegrees(math.Atan2(math.Sin(hPrime), math.Cos(hPrime)*math.Sin(phi)-math.Tan(deltaPrime)*math.Cos(phi))/deg + 180)
+
+	return p
+}
+
+// moonEcliptic holds the geometric geocentric position of the moon for the
+// mean equinox of date
+type moonEcliptic struct {
+	Longitude float64
+	Latitude  float64
+	Distance  float64
+}
+
+// geocentricMoon computes the position of the moon at the given Julian
+// ephemeris day from the periodic terms of Meeus, chapter 47
+func geocentricMoon(jde float64) moonEcliptic {
+	jce := (jde - 2451545) / 36525
+
+	lp := limitDegrees(218.3164477+jce*(481267.88123421+jce*(-0.0015786+jce*(1.0/538841-jce/65194000)))) * deg
+	d := (297.8501921 + jce*(445267.1114034+jce*(-0.0018819+jce*(1.0/545868-jce/113065000)))) * deg
+	m := (357.5291092 + jce*(35999.0502909+jce*(-0.0001536+jce/24490000))) * deg
+	mp := (134.9633964 + jce*(477198.8675055+jce*(0.0087414+jce*(1.0/69699-jce/14712000)))) * deg
+	f := (93.2720950 + jce*(483202.0175233+jce*(-0.0036539+jce*(-1.0/3526000+jce/863310000)))) * deg
+	e := 1 - jce*(0.002516+jce*0.0000074)
+
+	// Terms with the anomaly of the sun shrink with the eccentricity of the earth orbit
+	eccentricity := func(multiple float64) float64 {
+		switch math.Abs(multiple) {
+		case 1:
+			return e
+		case 2:
+			return e * e
+		}
+		return 1
+	}
+
+	var sumL, sumR, sumB float64
+	for _, term := range moonLRTerms {
+		arg := term[0]*d + term[1]*m + term[2]*mp + term[3]*f
+		sumL += term[4] * eccentricity(term[1]) * math.Sin(arg)
+		sumR += term[5] * eccentricity(term[1]) * math.Cos(arg)
+	}
+	for _, term := range moonBTerms {
+		sumB += term[4] * eccentricity(term[1]) * math.Sin(term[0]*d+term[1]*m+term[2]*mp+term[3]*f)
+	}
+
+	// Additive terms for the action of Venus, Jupiter and the flattening of the earth
+	a1 := (119.75 + 131.849*jce) * deg
+	a2 := (53.09 + 479264.290*jce) * deg
+	a3 := (313.45 + 481266.484*jce) * deg
+	sumL += 3958*math.Sin(a1) + 1962*math.Sin(lp-f) + 318*math.Sin(a2)
+	sumB += -2235*math.Sin(lp) + 382*math.Sin(a3) + 175*math.Sin(a1-f) + 175*math.Sin(a1+f) + 127*math.Sin(lp-mp) - 115*math.Sin(lp+mp)
+
+	return moonEcliptic{
+		Longitude: limitDegrees(lp/deg + sumL/1000000),
+		Latitude:  sumB / 1000000,
+		Distance:  385000.56 + sumR/1000,
+	}
+}
@@ -0,0 +1,38 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoonPositionAt(t *testing.T) {
+	// Example 47.a of Meeus, 1992 April 12 at 0h TD
+	observer := astro.NewObserver(0, 0)
+	observer.DeltaT = 0
+	p := astro.MoonPositionAt(time.Date(1992, 4, 12, 0, 0, 0, 0, time.UTC), observer)
+
+	assert.InDelta(t, 133.167265, p.Longitude, 1e-5)
+	assert.InDelta(t, -3.229126, p.Latitude, 1e-6)
+	assert.InDelta(t, 368409.7, p.Distance, 0.1)
+	assert.InDelta(t, 0.991990, p.HorizontalParallax, 1e-6)
+	assert.InDelta(t, 134.688470, p.RightAscension, 1e-5)
+	assert.InDelta(t, 13.768368, p.Declination, 1e-5)
+}
+
+func TestMoonPositionAtParallax(t *testing.T) {
+	observer := astro.NewObserver(48.2, 16.4)
+	at := time.Date(2024, 4, 8, 12, 0, 0, 0, time.UTC)
+	p := astro.MoonPositionAt(at, observer)
+
+	// Seen from the north the parallax shifts the moon southwards, by at most its horizontal parallax
+	assert.Less(t, p.TopocentricDeclination, p.Declination)
+	assert.Greater(t, p.TopocentricDeclination, p.Declination-p.HorizontalParallax)
+
+	// The moon is high in the sky at noon and close to perigee, so it looks larger than usual
+	assert.Greater(t, p.Distance-p.TopocentricDistance, 4000.0)
+	assert.Less(t, p.Distance-p.TopocentricDistance, 6400.0)
+	assert.InDelta(t, 0.280, p.SemiDiameter, 0.002)
+}
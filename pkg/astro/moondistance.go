@@ -0,0 +1,79 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// MoonExtremum is a perigee or apogee of the moon
+type MoonExtremum struct {
+	Time     time.Time
+	Distance float64 // Distance between the centers of earth and moon in kilometers
+}
+
+// MoonDistance returns the distance between the centers of earth and moon in
+// kilometers, from the periodic terms of Meeus, chapter 47. Accurate to a few
+// kilometers.
+func MoonDistance(t time.Time, deltaT float64) float64 {
+	return geocentricMoon(julianDay(t) + deltaT/86400).Distance
+}
+
+// MoonDistanceExtrema finds the perigees and apogees of the moon at or after
+// from and before to, in order
+func MoonDistanceExtrema(from time.Time, to time.Time, deltaT float64) ([]MoonExtremum, []MoonExtremum) {
+	distance := func(t time.Time) float64 {
+		return MoonDistance(t, deltaT)
+	}
+
+	perigees := []MoonExtremum{}
+	apogees := []MoonExtremum{}
+
+	// Extrema are two weeks apart, the step brackets each of them between three samples
+	const step = 6 * time.Hour
+	a, b := from.Add(-step), from
+	da, db := distance(a), distance(b)
+	for b.Before(to) {
+		c := b.Add(step)
+		dc := distance(c)
+
+		if db < da && db <= dc {
+			t := goldenSection(a, c, distance)
+			if !t.Before(from) && t.Before(to) {
+				perigees = append(perigees, MoonExtremum{Time: t, Distance: distance(t)})
+			}
+		}
+		if db > da && db >= dc {
+			t := goldenSection(a, c, func(t time.Time) float64 { return -distance(t) })
+			if !t.Before(from) && t.Before(to) {
+				apogees = append(apogees, MoonExtremum{Time: t, Distance: distance(t)})
+			}
+		}
+
+		a, b, da, db = b, c, db, dc
+	}
+
+	return perigees, apogees
+}
+
+// goldenSection finds the minimum of f between low and high, down to one second
+func goldenSection(low time.Time, high time.Time, f func(time.Time) float64) time.Time {
+	ratio := (math.Sqrt(5) - 1) / 2
+	span := float64(high.Sub(low))
+	x1 := high.Add(-time.Duration(ratio * span))
+	x2 := low.Add(time.Duration(ratio * span))
+	f1, f2 := f(x1), f(x2)
+
+	for high.Sub(low) > time.Second {
+		if f1 < f2 {
+			high, x2, f2 = x2, x1, f1
+			x1 = high.Add(-time.Duration(ratio * float64(high.Sub(low))))
+			f1 = f(x1)
+		} else {
+			low, x1, f1 = x1, x2, f2
+			x2 = low.Add(time.Duration(ratio * float64(high.Sub(low))))
+			f2 = f(x2)
+		}
+	}
+
+	return low.Add(high.Sub(low) / 2)
+}
@@ -0,0 +1,41 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoonDistance(t *testing.T) {
+	// Example 47.a of Meeus, 1992 April 12 at 0h TD
+	at := time.Date(1992, 4, 12, 0, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 368409.7, astro.MoonDistance(at, 0), 0.1)
+}
+
+func TestMoonDistanceExtrema(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	perigees, apogees := astro.MoonDistanceExtrema(from, from.AddDate(1, 0, 0), 69)
+
+	// A year has thirteen or fourteen of each
+	assert.Len(t, perigees, 13)
+	assert.Len(t, apogees, 14)
+
+	// The closest perigee of 2024 was on March 10th, the farthest apogee on October 2nd
+	closest, farthest := perigees[0], apogees[0]
+	for _, perigee := range perigees {
+		if perigee.Distance < closest.Distance {
+			closest = perigee
+		}
+	}
+	for _, apogee := range apogees {
+		if apogee.Distance > farthest.Distance {
+			farthest = apogee
+		}
+	}
+	assert.WithinDuration(t, time.Date(2024, 3, 10, 7, 3, 0, 0, time.UTC), closest.Time, 10*time.Minute)
+	assert.InDelta(t, 356895, closest.Distance, 5)
+	assert.WithinDuration(t, time.Date(2024, 10, 2, 19, 39, 0, 0, time.UTC), farthest.Time, 10*time.Minute)
+	assert.InDelta(t, 406516, farthest.Distance, 5)
+}
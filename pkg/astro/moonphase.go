@@ -0,0 +1,146 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// MoonPhase is one of the four principal phases of the moon
+type MoonPhase int
+
+const (
+	NewMoon MoonPhase = iota
+	FirstQuarter
+	FullMoon
+	LastQuarter
+)
+
+// SynodicMonth is the mean time from one new moon to the next in days
+const SynodicMonth = 29.530588861
+
+// MoonPhases returns the instants of a phase at or after from and before to,
+// accurate to within a minute (Meeus, chapter 49)
+func MoonPhases(phase MoonPhase, from time.Time, to time.Time, deltaT float64) []time.Time {
+	// Lunation number of the first new moon before from
+	k := math.Floor((julianDay(from)-2451550.09766)/SynodicMonth) - 1
+
+	phases := []time.Time{}
+	for ; ; k++ {
+		jde := moonPhaseJDE(phase, k+float64(phase)/4)
+		t := fromJulianDay(jde - deltaT/86400)
+		if !t.Before(to) {
+			return phases
+		}
+		if !t.Before(from) {
+			phases = append(phases, t)
+		}
+	}
+}
+
+// moonPhaseJDE returns the Julian ephemeris day of the phase of lunation k,
+// k is an integer for new moons and increases by 0.25 for the other phases
+func moonPhaseJDE(phase MoonPhase, k float64) float64 {
+	t := k / 1236.85
+	jde := 2451550.09766 + SynodicMonth*k + t*t*(0.00015437+t*(-0.000000150+t*0.00000000073))
+
+	e := 1 - t*(0.002516+t*0.0000074)
+	m := (2.5534 + 29.10535670*k - t*t*(0.0000014+t*0.00000011)) * deg
+	mp := (201.5643 + 385.81693528*k + t*t*(0.0107582+t*(0.00001238-t*0.000000058))) * deg
+	f := (160.7108 + 390.67050284*k - t*t*(0.0016118+t*(0.00000227-t*0.000000011))) * deg
+	omega := (124.7746 - 1.56375588*k + t*t*(0.0020672+t*0.00000215)) * deg
+
+	sin := math.Sin
+	var correction float64
+	switch phase {
+	case NewMoon, FullMoon:
+		// The two phases only differ in the leading terms
+		c := [...]float64{-0.40720, 0.17241, 0.01608, 0.01039, 0.00739, -0.00514, 0.00208}
+		if phase == FullMoon {
+			c = [...]float64{-0.40614, 0.17302, 0.01614, 0.01043, 0.00734, -0.00515, 0.00209}
+		}
+		correction = c[0]*sin(mp) +
+			c[1]*e*sin(m) +
+			c[2]*sin(2*mp) +
+			c[3]*sin(2*f) +
+			c[4]*e*sin(mp-m) +
+			c[5]*e*sin(mp+m) +
+			c[6]*e*e*sin(2*m) -
+			0.00111*sin(mp-2*f) -
+			0.00057*sin(mp+2*f) +
+			0.00056*e*sin(2*mp+m) -
+			0.00042*sin(3*mp) +
+			0.00042*e*sin(m+2*f) +
+			0.00038*e*sin(m-2*f) -
+			0.00024*e*sin(2*mp-m) -
+			0.00017*sin(omega) -
+			0.00007*sin(mp+2*m) +
+			0.00004*sin(2*mp-2*f) +
+			0.00004*sin(3*m) +
+			0.00003*sin(mp+m-2*f) +
+			0.00003*sin(2*mp+2*f) -
+			0.00003*sin(mp+m+2*f) +
+			0.00003*sin(mp-m+2*f) -
+			0.00002*sin(mp-m-2*f) -
+			0.00002*sin(3*mp+m) +
+			0.00002*sin(4*mp)
+
+	case FirstQuarter, LastQuarter:
+		correction = -0.62801*sin(mp) +
+			0.17172*e*sin(m) -
+			0.01183*e*sin(mp+m) +
+			0.00862*sin(2*mp) +
+			0.00804*sin(2*f) +
+			0.00454*e*sin(mp-m) +
+			0.00204*e*e*sin(2*m) -
+			0.00180*sin(mp-2*f) -
+			0.00070*sin(mp+2*f) -
+			0.00040*sin(3*mp) -
+			0.00034*e*sin(2*mp-m) +
+			0.00032*e*sin(m+2*f) +
+			0.00032*e*sin(m-2*f) -
+			0.00028*e*e*sin(mp+2*m) +
+			0.00027*e*sin(2*mp+m) -
+			0.00017*sin(omega) -
+			0.00005*sin(mp-m-2*f) +
+			0.00004*sin(2*mp+2*f) -
+			0.00004*sin(mp+m+2*f) +
+			0.00004*sin(mp-2*m) +
+			0.00003*sin(mp+m-2*f) +
+			0.00003*sin(3*m) +
+			0.00002*sin(2*mp-2*f) +
+			0.00002*sin(mp-m+2*f) -
+			0.00002*sin(3*mp+m)
+
+		w := 0.00306 - 0.00038*e*math.Cos(m) + 0.00026*math.Cos(mp) - 0.00002*math.Cos(mp-m) + 0.00002*math.Cos(mp+m) + 0.00002*math.Cos(2*f)
+		if phase == LastQuarter {
+			w = -w
+		}
+		correction += w
+	}
+
+	// Additional corrections from the planetary arguments
+	for _, a := range planetaryArguments {
+		correction += a[0] * math.Sin((a[1]+a[2]*k+a[3]*t*t)*deg)
+	}
+
+	return jde + correction
+}
+
+// Coefficient, constant, rate per lunation and rate per century squared of
+// the planetary arguments A1 to A14
+var planetaryArguments = [14][4]float64{
+	{0.000325, 299.77, 0.107408, -0.009173},
+	{0.000165, 251.88, 0.016321, 0},
+	{0.000164, 251.83, 26.651886, 0},
+	{0.000126, 349.42, 36.412478, 0},
+	{0.000110, 84.66, 18.206239, 0},
+	{0.000062, 141.74, 53.303771, 0},
+	{0.000060, 207.14, 2.453732, 0},
+	{0.000056, 154.84, 7.306860, 0},
+	{0.000047, 34.52, 27.261239, 0},
+	{0.000042, 207.19, 0.121824, 0},
+	{0.000040, 291.34, 1.844379, 0},
+	{0.000037, 161.72, 24.198154, 0},
+	{0.000035, 239.56, 25.513099, 0},
+	{0.000023, 331.55, 3.592518, 0},
+}
@@ -0,0 +1,38 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoonPhases(t *testing.T) {
+	tests := []struct {
+		phase    astro.MoonPhase
+		expected time.Time
+		deltaT   float64
+	}{
+		// Examples 49.a and 49.b of Meeus, given in dynamical time
+		{astro.NewMoon, time.Date(1977, 2, 18, 3, 37, 42, 0, time.UTC), 0},
+		{astro.LastQuarter, time.Date(2044, 1, 21, 23, 48, 17, 0, time.UTC), 0},
+		// Phases of April 2024 as published by the US Naval Observatory
+		{astro.NewMoon, time.Date(2024, 4, 8, 18, 21, 0, 0, time.UTC), 69},
+		{astro.FirstQuarter, time.Date(2024, 4, 15, 19, 13, 0, 0, time.UTC), 69},
+		{astro.FullMoon, time.Date(2024, 4, 23, 23, 49, 0, 0, time.UTC), 69},
+		{astro.LastQuarter, time.Date(2024, 5, 1, 11, 27, 0, 0, time.UTC), 69},
+	}
+
+	for _, tt := range tests {
+		from := tt.expected.Add(-7 * 24 * time.Hour)
+		phases := astro.MoonPhases(tt.phase, from, from.Add(14*24*time.Hour), tt.deltaT)
+		if assert.Len(t, phases, 1) {
+			assert.WithinDuration(t, tt.expected, phases[0], time.Minute)
+		}
+	}
+
+	// A year has twelve or thirteen full moons
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Len(t, astro.MoonPhases(astro.FullMoon, from, from.AddDate(1, 0, 0), 69), 12)
+}
@@ -0,0 +1,318 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Planet identifies one of the planets visible to the naked eye
+type Planet int
+
+const (
+	Mercury Planet = iota
+	Venus
+	Mars
+	Jupiter
+	Saturn
+)
+
+// Planets lists all supported planets from the innermost outwards
+var Planets = []Planet{Mercury, Venus, Mars, Jupiter, Saturn}
+
+// String returns the lowercase english name of the planet
+func (p Planet) String() string {
+	switch p {
+	case Mercury:
+		return "mercury"
+	case Venus:
+		return "venus"
+	case Mars:
+		return "mars"
+	case Jupiter:
+		return "jupiter"
+	case Saturn:
+		return "saturn"
+	}
+	return "unknown"
+}
+
+// PlanetPosition holds the apparent position of a planet seen from the
+// observer, angles are in degrees and distances in astronomical units.
+type PlanetPosition struct {
+	JulianDay float64
+
+	// Heliocentric ecliptic coordinates for the equinox of date
+	HeliocentricLongitude float64
+	HeliocentricLatitude  float64
+	SunDistance           float64
+
+	// Geocentric coordinates corrected for light time and nutation
+	Longitude      float64
+	Latitude       float64
+	Distance       float64
+	RightAscension float64
+	Declination    float64
+
+	HourAngle     float64
+	TrueElevation float64 // Elevation angle without refraction
+	Elevation     float64 // Elevation angle corrected for refraction
+	Azimuth       float64 // Azimuth, eastward from north
+
+	Elongation float64 // Angle between sun and planet, positive east of the sun (evening sky)
+	PhaseAngle float64 // Angle between sun and earth seen from the planet
+	Magnitude  float64 // Apparent visual magnitude
+}
+
+// AstronomicalUnit in kilometers
+const AstronomicalUnit = 149597870.7
+
+// Constant of aberration in arc seconds
+const aberrationConstant = 20.49552
+
+// Light time for one astronomical unit in days
+const lightTimePerAU = 0.0057755183
+
+// PlanetaryPosition computes the apparent position of a planet at t for obs,
+// corrected for light time, aberration and nutation. The earth comes from the
+// VSOP87 series of the SPA, the planets from VSOP87D as truncated by Meeus.
+// Between 1900 and 2100 the geocentric positions agree with the published
+// ones to one or two arc seconds (Meeus example 33.a, the transits of Mercury
+// and Venus, the great conjunction of 2020). The truncation error grows
+// slowly with the distance from J2000, to about ten arc seconds for the
+// inner planets and half an arc minute for Jupiter and Saturn at 4000 years.
+func PlanetaryPosition(planet Planet, t time.Time, obs Observer) PlanetPosition {
+	jd := julianDay(t)
+	jde := jd + obs.DeltaT/86400
+	earth := geocentricSun(jd, obs.DeltaT)
+
+	var p PlanetPosition
+	p.JulianDay = jd
+
+	// Rectangular heliocentric coordinates of the earth
+	x0, y0, z0 := rectangular(earth.L, earth.B, earth.R)
+
+	// Geocentric vector of the planet, corrected for the light time
+	var x, y, z float64
+	tau := 0.0
+	for i := 0; i < 3; i++ {
+		p.HeliocentricLongitude, p.HeliocentricLatitude, p.SunDistance = heliocentricPlanet(planet, jde-tau)
+		xp, yp, zp := rectangular(p.HeliocentricLongitude, p.HeliocentricLatitude, p.SunDistance)
+		x, y, z = xp-x0, yp-y0, zp-z0
+		p.Distance = math.Sqrt(x*x + y*y + z*z)
+		tau = lightTimePerAU * p.Distance
+	}
+
+	p.Longitude = limitDegrees(math.Atan2(y, x) / deg)
+	p.Latitude = math.Atan2(z, math.Hypot(x, y)) / deg
+
+	// Annual aberration from the motion of the earth, Meeus 23.2 without the
+	// eccentricity terms, and nutation in longitude
+	sunLongitude := (earth.L + 180 - p.Longitude) * deg
+	latitude := p.Latitude * deg
+	p.Longitude = limitDegrees(p.Longitude - aberrationConstant*math.Cos(sunLongitude)/math.Cos(latitude)/3600 + earth.DeltaPsi)
+	p.Latitude -= aberrationConstant * math.Sin(sunLongitude) * math.Sin(latitude) / 3600
+
+	// Equatorial coordinates with the true obliquity of the ecliptic
+	lambda := p.Longitude * deg
+	beta := p.Latitude * deg
+	epsilon := earth.Epsilon * deg
+	p.RightAscension = limitDegrees(math.Atan2(math.Sin(lambda)*math.Cos(epsilon)-math.Tan(beta)*math.Sin(epsilon), math.Cos(lambda)) / deg)
+	p.Declination = math.Asin(math.Sin(beta)*math.Cos(epsilon)+math.Cos(beta)*math.Sin(epsilon)*math.Sin(lambda)) / deg
+
+	// Horizontal coordinates, the parallax of the planets stays below an arc minute
+	p.HourAngle = limitDegrees(earth.siderealTime + obs.Longitude - p.RightAscension)
+	phi := obs.Latitude * deg
+	delta := p.Declination * deg
+	h := p.HourAngle * deg
+	p.TrueElevation = math.Asin(math.Sin(phi)*math.Sin(delta)+math.Cos(phi)*math.Cos(delta)*math.Cos(h)) / deg
+	p.Elevation = p.TrueElevation + Refraction(p.TrueElevation, obs.Pressure, obs.Temperature)
+	p.Azimuth = limitDegrees(math.Atan2(math.Sin(h), math.Cos(h)*math.Sin(phi)-math.Tan(delta)*math.Cos(phi))/deg + 180)
+
+	// Elongation and phase angle from the triangle sun, earth and planet
+	r, delta2, earthR := p.SunDistance, p.Distance, earth.R
+	p.Elongation = math.Acos(clamp((earthR*earthR+delta2*delta2-r*r)/(2*earthR*delta2))) / deg
+	if limitDegrees180(p.Longitude-earth.Lambda) < 0 {
+		p.Elongation = -p.Elongation
+	}
+	p.PhaseAngle = math.Acos(clamp((r*r+delta2*delta2-earthR*earthR)/(2*r*delta2))) / deg
+
+	p.Magnitude = magnitude(planet, p, (jde-2451545)/36525)
+
+	return p
+}
+
+// PlanetRiseTransitSet finds the first rise, transit and set of a planet
+// between from and to. Events that don't happen in the interval are zero.
+// The horizon is lowered by the standard refraction and HorizonDip.
+func PlanetRiseTransitSet(planet Planet, from time.Time, to time.Time, obs Observer) (time.Time, time.Time, time.Time) {
+	rises, transits, sets := PlanetEvents(planet, from, to, obs)
+
+	first := func(events []time.Time) time.Time {
+		if len(events) == 0 {
+			return time.Time{}
+		}
+		return events[0]
+	}
+	return first(rises), first(transits), first(sets)
+}
+
+// PlanetEvents finds all rises, transits and sets of a planet at or after
+// from and before to, each in order, walking the interval once. The horizon
+// is lowered by the standard refraction and HorizonDip.
+func PlanetEvents(planet Planet, from time.Time, to time.Time, obs Observer) ([]time.Time, []time.Time, []time.Time) {
+	h0 := -(atmosRefract + HorizonDip(obs.Elevation))
+	path := newPlanetPath(planet, from, to, obs)
+
+	altitude := func(t time.Time) float64 {
+		elevation, _ := path.at(t)
+		return elevation - h0
+	}
+	hourAngle := func(t time.Time) float64 {
+		_, h := path.at(t)
+		return h
+	}
+
+	var rises, transits, sets []time.Time
+
+	// Scan the interval, the planets move slowly enough for the step to catch every event
+	const step = 10 * time.Minute
+	prev := from
+	prevAltitude, prevHourAngle := altitude(prev), hourAngle(prev)
+	for prev.Before(to) {
+		next := prev.Add(step)
+		if next.After(to) {
+			next = to
+		}
+		nextAltitude, nextHourAngle := altitude(next), hourAngle(next)
+
+		if prevAltitude < 0 && nextAltitude >= 0 {
+			rises = append(rises, bisect(prev, next, altitude))
+		}
+		if prevAltitude >= 0 && nextAltitude < 0 {
+			sets = append(sets, bisect(prev, next, altitude))
+		}
+		// The hour angle passes zero at the transit and jumps from +180 to -180 at the lower one
+		if prevHourAngle < 0 && nextHourAngle >= 0 && nextHourAngle-prevHourAngle < 180 {
+			transits = append(transits, bisect(prev, next, hourAngle))
+		}
+
+		prev, prevAltitude, prevHourAngle = next, nextAltitude, nextHourAngle
+	}
+
+	return rises, transits, sets
+}
+
+// Rotation of the earth against the equinox in degrees per day
+const siderealRate = 360.98564736629
+
+// Step between the positions a planetPath is computed at
+const planetPathStep = 3 * time.Hour
+
+// planetPath holds positions of a planet at fixed steps over an interval.
+// In between the right ascension and declination are interpolated linearly
+// and the sidereal time advances at its mean rate, which keeps the altitude
+// within about an arc second of the full computation.
+type planetPath struct {
+	from      time.Time
+	latitude  float64
+	positions []PlanetPosition
+}
+
+// newPlanetPath computes the positions of a planet from from up to at least to
+func newPlanetPath(planet Planet, from time.Time, to time.Time, obs Observer) planetPath {
+	path := planetPath{from: from, latitude: obs.Latitude}
+	for t := from; ; t = t.Add(planetPathStep) {
+		path.positions = append(path.positions, PlanetaryPosition(planet, t, obs))
+		if len(path.positions) > 1 && !t.Before(to) {
+			return path
+		}
+	}
+}
+
+// at returns the true elevation and the hour angle (-180 to 180) of the
+// planet at t, in degrees
+func (p planetPath) at(t time.Time) (float64, float64) {
+	i := int(t.Sub(p.from) / planetPathStep)
+	i = max(0, min(i, len(p.positions)-2))
+	a, b := p.positions[i], p.positions[i+1]
+
+	elapsed := t.Sub(p.from.Add(time.Duration(i) * planetPathStep))
+	f := elapsed.Hours() / planetPathStep.Hours()
+	ra := limitDegrees180(b.RightAscension-a.RightAscension) * f
+	delta := (a.Declination + (b.Declination-a.Declination)*f) * deg
+	h := limitDegrees180(a.HourAngle + siderealRate*elapsed.Hours()/24 - ra)
+
+	phi := p.latitude * deg
+	elevation := math.Asin(math.Sin(phi)*math.Sin(delta)+math.Cos(phi)*math.Cos(delta)*math.Cos(h*deg)) / deg
+	return elevation, h
+}
+
+// bisect finds the time between low and high at which f changes its sign,
+// down to one second
+func bisect(low time.Time, high time.Time, f func(time.Time) float64) time.Time {
+	lowValue := f(low)
+	for high.Sub(low) > time.Second {
+		mid := low.Add(high.Sub(low) / 2)
+		midValue := f(mid)
+		if math.Signbit(midValue) == math.Signbit(lowValue) {
+			low, lowValue = mid, midValue
+		} else {
+			high = mid
+		}
+	}
+	return low
+}
+
+// heliocentricPlanet returns ecliptic longitude and latitude in degrees for
+// the equinox of date and the radius vector of a planet at the given
+// Julian ephemeris day
+func heliocentricPlanet(planet Planet, jde float64) (float64, float64, float64) {
+	jme := (jde - 2451545) / 365250
+	terms := planetTerms[planet]
+	return limitDegrees(earthValue(terms[0], jme) / deg), earthValue(terms[1], jme) / deg, earthValue(terms[2], jme)
+}
+
+// magnitude returns the apparent visual magnitude using the formulas of the
+// Astronomical Almanac as given by Meeus, chapter 41
+func magnitude(planet Planet, p PlanetPosition, t float64) float64 {
+	i := p.PhaseAngle
+	m := 5 * math.Log10(p.SunDistance*p.Distance)
+
+	switch planet {
+	case Mercury:
+		return m - 0.42 + 0.0380*i - 0.000273*i*i + 0.000002*i*i*i
+	case Venus:
+		return m - 4.40 + 0.0009*i + 0.000239*i*i - 0.00000065*i*i*i
+	case Mars:
+		return m - 1.52 + 0.016*i
+	case Jupiter:
+		return m - 9.40 + 0.005*i
+	case Saturn:
+		// The rings add brightness depending on their tilt towards the earth and the sun
+		ringInclination := (28.075216 - 0.012998*t + 0.000004*t*t) * deg
+		ringNode := (169.508470 + 1.394681*t + 0.000412*t*t) * deg
+		ring := func(longitude float64, latitude float64) (float64, float64) {
+			l, b := longitude*deg, latitude*deg
+			sinB := math.Sin(ringInclination)*math.Cos(b)*math.Sin(l-ringNode) - math.Cos(ringInclination)*math.Sin(b)
+			u := math.Atan2(math.Sin(ringInclination)*math.Sin(b)+math.Cos(ringInclination)*math.Cos(b)*math.Sin(l-ringNode), math.Cos(b)*math.Cos(l-ringNode))
+			return sinB, u / deg
+		}
+		sinB, u1 := ring(p.Longitude, p.Latitude)
+		_, u2 := ring(p.HeliocentricLongitude, p.HeliocentricLatitude)
+		deltaU := math.Abs(limitDegrees180(u1 - u2))
+		return m - 8.88 + 0.044*deltaU - 2.60*math.Abs(sinB) + 1.25*sinB*sinB
+	}
+
+	return math.NaN()
+}
+
+// rectangular converts spherical ecliptic coordinates in degrees to a vector
+func rectangular(longitude float64, latitude float64, radius float64) (float64, float64, float64) {
+	l, b := longitude*deg, latitude*deg
+	return radius * math.Cos(b) * math.Cos(l), radius * math.Cos(b) * math.Sin(l), radius * math.Sin(b)
+}
+
+// clamp limits the argument of an inverse cosine to -1..1
+func clamp(value float64) float64 {
+	return math.Max(-1, math.Min(1, value))
+}
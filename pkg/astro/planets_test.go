@@ -0,0 +1,136 @@
+package astro_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+// Venus on 1992 December 20 at 0h TD, examples 33.a and 41.a of Meeus,
+// Astronomical Algorithms
+func TestPlanetaryPositionVenus(t *testing.T) {
+	observer := astro.NewObserver(0, 0)
+	observer.DeltaT = 59
+	at := time.Date(1992, 12, 20, 0, 0, 0, 0, time.UTC).Add(-59 * time.Second)
+
+	p := astro.PlanetaryPosition(astro.Venus, at, observer)
+
+	assert.InDelta(t, 26.11428, p.HeliocentricLongitude, 0.01)
+	assert.InDelta(t, 316.17291, p.RightAscension, 0.0001)
+	assert.InDelta(t, -18.88801, p.Declination, 0.0001)
+	assert.InDelta(t, 0.910947, p.Distance, 0.000002)
+	assert.InDelta(t, 0.724604, p.SunDistance, 0.000002)
+	assert.InDelta(t, 72.96, p.PhaseAngle, 0.01)
+	assert.InDelta(t, -4.2, p.Magnitude, 0.1)
+
+	// Venus is an evening star east of the sun
+	assert.Greater(t, p.Elongation, 40.0)
+	assert.Less(t, p.Elongation, 48.0)
+}
+
+// Oppositions and conjunctions as published by the US Naval Observatory and
+// NASA, the instants are rounded to the minute (Saturn to the hour)
+func TestPlanetaryPositionOuterPlanets(t *testing.T) {
+	observer := astro.NewObserver(0, 0)
+	observer.DeltaT = 69
+
+	oppositions := []struct {
+		planet astro.Planet
+		at     time.Time
+	}{
+		{astro.Mars, time.Date(2003, 8, 28, 17, 56, 0, 0, time.UTC)},
+		{astro.Mars, time.Date(2020, 10, 13, 23, 20, 0, 0, time.UTC)},
+		{astro.Jupiter, time.Date(2022, 9, 26, 19, 33, 0, 0, time.UTC)},
+		{astro.Saturn, time.Date(2022, 8, 14, 17, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range oppositions {
+		// At opposition the planet stands 180° from the sun in longitude
+		p := astro.PlanetaryPosition(tc.planet, tc.at, observer)
+		sun := astro.SolarPositionSPA(tc.at, observer)
+		difference := math.Mod(p.Longitude-sun.Lambda+360, 360)
+		assert.InDelta(t, 180, difference, 0.01, tc.planet.String())
+	}
+
+	// Closest approach of Mars on 2003 August 27 at 9:51 UT, 55.76 million km
+	mars := astro.PlanetaryPosition(astro.Mars, time.Date(2003, 8, 27, 9, 51, 0, 0, time.UTC), observer)
+	assert.InDelta(t, 0.372719, mars.Distance, 0.00002)
+
+	// Great conjunction of Jupiter and Saturn on 2020 December 21 at 18:20
+	// UT, 6.1 arc minutes apart
+	at := time.Date(2020, 12, 21, 18, 20, 0, 0, time.UTC)
+	jupiter := astro.PlanetaryPosition(astro.Jupiter, at, observer)
+	saturn := astro.PlanetaryPosition(astro.Saturn, at, observer)
+	assert.InDelta(t, 6.1, separation(jupiter, saturn)*60, 0.05)
+}
+
+// Least distances between the centres of the planet and the sun at the
+// transits of Mercury and Venus, as published by NASA (Espenak)
+func TestPlanetaryPositionTransits(t *testing.T) {
+	observer := astro.NewObserver(0, 0)
+	observer.DeltaT = 67
+
+	transits := []struct {
+		planet     astro.Planet
+		at         time.Time
+		arcseconds float64
+	}{
+		{astro.Venus, time.Date(2004, 6, 8, 8, 19, 44, 0, time.UTC), 626.9},
+		{astro.Venus, time.Date(2012, 6, 6, 1, 29, 36, 0, time.UTC), 554.4},
+		{astro.Mercury, time.Date(2016, 5, 9, 14, 57, 26, 0, time.UTC), 318.5},
+		{astro.Mercury, time.Date(2019, 11, 11, 15, 19, 48, 0, time.UTC), 75.9},
+	}
+	for _, tc := range transits {
+		p := astro.PlanetaryPosition(tc.planet, tc.at, observer)
+		sun := astro.SolarPositionSPA(tc.at, observer)
+		longitude := (p.Longitude - sun.Lambda) * math.Cos(p.Latitude*math.Pi/180)
+		latitude := p.Latitude + sun.B
+		assert.InDelta(t, tc.arcseconds, math.Hypot(longitude, latitude)*3600, 2, tc.planet.String())
+	}
+}
+
+// separation returns the angle between two planets in degrees
+func separation(a astro.PlanetPosition, b astro.PlanetPosition) float64 {
+	const deg = math.Pi / 180
+	cos := math.Sin(a.Declination*deg)*math.Sin(b.Declination*deg) +
+		math.Cos(a.Declination*deg)*math.Cos(b.Declination*deg)*math.Cos((a.RightAscension-b.RightAscension)*deg)
+	return math.Acos(math.Min(1, cos)) / deg
+}
+
+func TestPlanetRiseTransitSet(t *testing.T) {
+	observer := astro.NewObserver(48.2, 16.4)
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	for _, planet := range astro.Planets {
+		rise, transit, set := astro.PlanetRiseTransitSet(planet, from, to, observer)
+		assert.False(t, transit.IsZero(), planet.String())
+
+		// The planet culminates at the transit
+		culmination := astro.PlanetaryPosition(planet, transit, observer)
+		before := astro.PlanetaryPosition(planet, transit.Add(-10*time.Minute), observer)
+		after := astro.PlanetaryPosition(planet, transit.Add(10*time.Minute), observer)
+		assert.Greater(t, culmination.TrueElevation, before.TrueElevation, planet.String())
+		assert.Greater(t, culmination.TrueElevation, after.TrueElevation, planet.String())
+
+		// Rise and set are on the standard horizon
+		for _, event := range []time.Time{rise, set} {
+			if !event.IsZero() {
+				assert.InDelta(t, -0.5667, astro.PlanetaryPosition(planet, event, observer).TrueElevation, 0.01, planet.String())
+			}
+		}
+	}
+}
+
+func TestPlanetRiseTransitSetPolar(t *testing.T) {
+	// Saturn's declination stays near -10° in 2024, it never rises at 85° north
+	observer := astro.NewObserver(85, 0)
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	rise, transit, set := astro.PlanetRiseTransitSet(astro.Saturn, from, from.Add(24*time.Hour), observer)
+	assert.True(t, rise.IsZero())
+	assert.True(t, set.IsZero())
+	assert.False(t, transit.IsZero())
+}
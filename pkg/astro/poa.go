@@ -0,0 +1,82 @@
+package astro
+
+import "math"
+
+// PlaneOfArray holds the irradiance on a tilted plane in W/m²
+type PlaneOfArray struct {
+	Global        float64 // Sum of all components
+	Direct        float64 // Beam irradiance from the sun disk
+	SkyDiffuse    float64 // Diffuse irradiance from the sky dome
+	GroundDiffuse float64 // Irradiance reflected by the ground
+}
+
+// AngleOfIncidence returns the angle in degrees between the sun and the
+// normal of a plane with the given tilt from horizontal and azimuth
+// clockwise from north. Above 90° the sun shines on the back of the plane.
+func AngleOfIncidence(zenith float64, azimuth float64, tilt float64, surfaceAzimuth float64) float64 {
+	cosAOI := math.Cos(zenith*deg)*math.Cos(tilt*deg) + math.Sin(zenith*deg)*math.Sin(tilt*deg)*math.Cos((azimuth-surfaceAzimuth)*deg)
+	return math.Acos(clamp(cosAOI)) / deg
+}
+
+// PlaneOfArrayHayDavies transposes the irradiance onto a plane with the Hay
+// and Davies (1980) model, which treats the diffuse irradiance as partly
+// circumsolar and partly isotropic. zenith is the apparent zenith angle and
+// aoi the angle of incidence in degrees, albedo the share of the global
+// irradiance the ground reflects.
+func PlaneOfArrayHayDavies(irradiance Irradiance, zenith float64, aoi float64, tilt float64, dniExtra float64, albedo float64) PlaneOfArray {
+	if zenith >= 90 {
+		return PlaneOfArray{}
+	}
+
+	cosAOI := math.Max(math.Cos(aoi*deg), 0)
+	// Keep the ratio finite with the sun at the horizon
+	cosZenith := math.Max(math.Cos(zenith*deg), math.Cos(89*deg))
+
+	anisotropy := irradiance.DNI / dniExtra
+	var poa PlaneOfArray
+	poa.Direct = irradiance.DNI * cosAOI
+	poa.SkyDiffuse = irradiance.DHI * (anisotropy*cosAOI/cosZenith + (1-anisotropy)*(1+math.Cos(tilt*deg))/2)
+	poa.GroundDiffuse = irradiance.GHI * albedo * (1 - math.Cos(tilt*deg)) / 2
+	poa.Global = poa.Direct + poa.SkyDiffuse + poa.GroundDiffuse
+	return poa
+}
+
+// SingleAxisTracker returns the rotation of a single-axis tracker and the
+// resulting tilt and azimuth of its modules, all in degrees, following the
+// pvlib implementation of Lorenzo et al. (2011). The axis is tilted by
+// axisTilt towards axisAzimuth. The rotation turns clockwise looking along
+// the axis towards axisAzimuth, towards the west for an axis pointing south,
+// and is limited to ±maxAngle. Backtracking turns the
+// modules back to avoid rows shading each other, which depends on the
+// ground coverage ratio gcr, the module width over the row spacing. Zero
+// disables backtracking. With the sun below the horizon the tracker stows
+// flat.
+func SingleAxisTracker(zenith float64, azimuth float64, axisTilt float64, axisAzimuth float64, maxAngle float64, gcr float64) (float64, float64, float64) {
+	rotation := 0.0
+	if zenith < 90 {
+		// Sun vector in earth coordinates, x east, y north and z up
+		x := math.Sin(zenith*deg) * math.Sin(azimuth*deg)
+		y := math.Sin(zenith*deg) * math.Cos(azimuth*deg)
+		z := math.Cos(zenith * deg)
+
+		// Sun vector in the coordinates of the tracker axis
+		xp := x*math.Cos(axisAzimuth*deg) - y*math.Sin(axisAzimuth*deg)
+		zp := x*math.Sin(axisTilt*deg)*math.Sin(axisAzimuth*deg) + y*math.Sin(axisTilt*deg)*math.Cos(axisAzimuth*deg) + z*math.Cos(axisTilt*deg)
+
+		// Rotation facing the sun, then turned back while the rows shade each other
+		rotation = math.Atan2(xp, zp) / deg
+		if gcr > 0 {
+			if shade := math.Abs(math.Cos(rotation*deg) / gcr); shade < 1 {
+				rotation -= math.Copysign(math.Acos(shade)/deg, rotation)
+			}
+		}
+		rotation = math.Max(-maxAngle, math.Min(maxAngle, rotation))
+	}
+
+	tilt := math.Acos(math.Cos(rotation*deg)*math.Cos(axisTilt*deg)) / deg
+	surfaceAzimuth := axisAzimuth
+	if tilt > 0 {
+		surfaceAzimuth = limitDegrees(axisAzimuth + math.Atan2(math.Sin(rotation*deg), math.Cos(rotation*deg)*math.Sin(axisTilt*deg))/deg)
+	}
+	return rotation, tilt, surfaceAzimuth
+}
@@ -0,0 +1,72 @@
+package astro_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAngleOfIncidence(t *testing.T) {
+	// Plane facing the sun, a flat plane and the sun behind a vertical plane
+	assert.InDelta(t, 0, astro.AngleOfIncidence(30, 180, 30, 180), 1e-6)
+	assert.InDelta(t, 40, astro.AngleOfIncidence(40, 123, 0, 180), 1e-9)
+	assert.InDelta(t, 150, astro.AngleOfIncidence(60, 0, 90, 180), 1e-9)
+}
+
+func TestPlaneOfArrayHayDavies(t *testing.T) {
+	irradiance := astro.ClearSkyIneichenPerez(40, 3, 0, astro.SolarConstant)
+
+	// A flat plane gets the global horizontal irradiance
+	flat := astro.PlaneOfArrayHayDavies(irradiance, 40, 40, 0, astro.SolarConstant, 0.2)
+	assert.InDelta(t, irradiance.GHI, flat.Global, 1e-9)
+	assert.Equal(t, 0.0, flat.GroundDiffuse)
+
+	// Facing the sun gains, the beam arrives at a right angle
+	facing := astro.PlaneOfArrayHayDavies(irradiance, 40, 0, 40, astro.SolarConstant, 0.2)
+	assert.Greater(t, facing.Global, flat.Global)
+	assert.InDelta(t, irradiance.DNI, facing.Direct, 1e-9)
+	assert.InDelta(t, facing.Global, facing.Direct+facing.SkyDiffuse+facing.GroundDiffuse, 1e-9)
+
+	// The sun behind the plane leaves the diffuse irradiance only
+	behind := astro.PlaneOfArrayHayDavies(irradiance, 40, 130, 90, astro.SolarConstant, 0.2)
+	assert.Equal(t, 0.0, behind.Direct)
+	assert.Greater(t, behind.Global, 0.0)
+
+	assert.Equal(t, astro.PlaneOfArray{}, astro.PlaneOfArrayHayDavies(astro.Irradiance{}, 95, 95, 30, astro.SolarConstant, 0.2))
+}
+
+func TestSingleAxisTracker(t *testing.T) {
+	// Horizontal north-south axis, morning sun in the east
+	rotation, tilt, azimuth := astro.SingleAxisTracker(60, 90, 0, 180, 90, 0)
+	assert.InDelta(t, -60, rotation, 1e-9)
+	assert.InDelta(t, 60, tilt, 1e-9)
+	assert.InDelta(t, 90, azimuth, 1e-9)
+
+	// Evening sun in the west with the axis pointing north
+	rotation, tilt, azimuth = astro.SingleAxisTracker(60, 270, 0, 0, 90, 0)
+	assert.InDelta(t, -60, rotation, 1e-9)
+	assert.InDelta(t, 60, tilt, 1e-9)
+	assert.InDelta(t, 270, azimuth, 1e-9)
+
+	// Noon sun in the south keeps the modules flat
+	rotation, tilt, _ = astro.SingleAxisTracker(30, 180, 0, 180, 60, 0.4)
+	assert.InDelta(t, 0, rotation, 1e-9)
+	assert.InDelta(t, 0, tilt, 1e-9)
+
+	// No shading at 60°, the low sun at 80° is backtracked
+	rotation, _, _ = astro.SingleAxisTracker(60, 90, 0, 0, 90, 0.4)
+	assert.InDelta(t, 60, rotation, 1e-9)
+	rotation, _, _ = astro.SingleAxisTracker(80, 90, 0, 0, 90, 0.4)
+	assert.InDelta(t, 80-math.Acos(math.Cos(80*math.Pi/180)/0.4)*180/math.Pi, rotation, 1e-9)
+
+	// Without backtracking the rotation limit applies
+	rotation, _, _ = astro.SingleAxisTracker(80, 90, 0, 0, 60, 0)
+	assert.InDelta(t, 60, rotation, 1e-9)
+
+	// Stowed at night
+	rotation, tilt, _ = astro.SingleAxisTracker(100, 0, 0, 0, 60, 0.4)
+	assert.Equal(t, 0.0, rotation)
+	assert.Equal(t, 0.0, tilt)
+}
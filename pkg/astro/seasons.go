@@ -0,0 +1,41 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Apparent solar longitudes in degrees at which the seasons of the northern
+// hemisphere begin
+const (
+	MarchEquinox     = 0.0
+	JuneSolstice     = 90.0
+	SeptemberEquinox = 180.0
+	DecemberSolstice = 270.0
+)
+
+// SolarLongitudeTime returns the instant in the given year at which the
+// apparent geocentric longitude of the sun reaches longitude, in degrees.
+// With the longitudes of the equinoxes and solstices this gives the start of
+// the astronomical seasons to within seconds (Meeus, chapter 27).
+func SolarLongitudeTime(longitude float64, year int, deltaT float64) time.Time {
+	// The sun moves about a degree per day starting from the March equinox
+	near := time.Date(year, 3, 20, 12, 0, 0, 0, time.UTC)
+	jd := julianDay(near) + limitDegrees(longitude)*365.2422/360
+
+	for i := 0; i < 20; i++ {
+		lambda := geocentricSun(jd, deltaT).Lambda
+		correction := 58 * math.Sin((longitude-lambda)*deg)
+		jd += correction
+		if math.Abs(correction) < 1e-6 {
+			break
+		}
+	}
+
+	return fromJulianDay(jd)
+}
+
+// fromJulianDay converts a Julian day in UT to a time
+func fromJulianDay(jd float64) time.Time {
+	return time.Unix(0, int64((jd-2440587.5)*float64(24*time.Hour))).UTC()
+}
@@ -0,0 +1,27 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolarLongitudeTime(t *testing.T) {
+	// Equinoxes and solstices of 2024 as published by the US Naval Observatory
+	tests := []struct {
+		longitude float64
+		expected  time.Time
+	}{
+		{astro.MarchEquinox, time.Date(2024, 3, 20, 3, 6, 0, 0, time.UTC)},
+		{astro.JuneSolstice, time.Date(2024, 6, 20, 20, 51, 0, 0, time.UTC)},
+		{astro.SeptemberEquinox, time.Date(2024, 9, 22, 12, 44, 0, 0, time.UTC)},
+		{astro.DecemberSolstice, time.Date(2024, 12, 21, 9, 20, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		actual := astro.SolarLongitudeTime(tt.longitude, 2024, 69)
+		assert.WithinDuration(t, tt.expected, actual, time.Minute, "longitude %v", tt.longitude)
+	}
+}
@@ -0,0 +1,330 @@
+// Package astro implements astronomical algorithms that go beyond the
+// precision of suncalc.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Observer describes the location and atmosphere the positions are computed for
+type Observer struct {
+	Latitude    float64 // Degrees, positive north
+	Longitude   float64 // Degrees, positive east
+	Elevation   float64 // Meters above sea level
+	Pressure    float64 // Annual average local pressure in millibars
+	Temperature float64 // Annual average local temperature in °C
+	DeltaT      float64 // Difference between terrestrial and universal time in seconds
+}
+
+// NewObserver returns an observer at sea level in standard atmosphere with
+// ΔT estimated for the current year.
+func NewObserver(latitude float64, longitude float64) Observer {
+	return Observer{
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Pressure:    StandardPressure,
+		Temperature: StandardTemperature,
+		DeltaT:      DeltaT(time.Now()),
+	}
+}
+
+const (
+	// StandardPressure is the sea level pressure of the standard atmosphere in millibars
+	StandardPressure = 1013.25
+	// StandardTemperature is the sea level temperature of the standard atmosphere in °C
+	StandardTemperature = 15.0
+
+	// Apparent radius of the sun in degrees
+	sunRadius = 0.26667
+	// Atmospheric refraction at sunrise and sunset in degrees
+	atmosRefract = 0.5667
+)
+
+// SPAPosition holds the results of the NREL Solar Position Algorithm,
+// angles are in degrees.
+type SPAPosition struct {
+	JulianDay float64
+
+	L float64 // Heliocentric longitude
+	B float64 // Heliocentric latitude
+	R float64 // Earth radius vector in astronomical units
+
+	DeltaPsi     float64 // Nutation in longitude
+	DeltaEpsilon float64 // Nutation in obliquity
+	Epsilon      float64 // True obliquity of the ecliptic
+	Lambda       float64 // Apparent sun longitude
+
+	RightAscension float64 // Geocentric
+	Declination    float64 // Geocentric
+	HourAngle      float64 // Observer local hour angle
+
+	TopocentricRightAscension float64
+	TopocentricDeclination    float64
+	TopocentricHourAngle      float64
+
+	TrueElevation float64 // Topocentric elevation angle without refraction
+	Elevation     float64 // Topocentric elevation angle corrected for refraction
+	Zenith        float64 // Topocentric zenith angle
+	Azimuth       float64 // Topocentric azimuth, eastward from north
+}
+
+// SolarPositionSPA computes the sun position at t for obs with the NREL Solar
+// Position Algorithm (Reda and Andreas, 2004), accurate to ±0.0003° between
+// the years -2000 and 6000.
+func SolarPositionSPA(t time.Time, obs Observer) SPAPosition {
+	p := geocentricSun(julianDay(t), obs.DeltaT)
+
+	// Observer local hour angle
+	p.HourAngle = limitDegrees(p.siderealTime + obs.Longitude - p.RightAscension)
+
+	// Parallax correction for the observer position on the earth's surface
+	xi := 8.794 / (3600 * p.R)
+	phi := obs.Latitude * deg
+	u := math.Atan(0.99664719 * math.Tan(phi))
+	x := math.Cos(u) + obs.Elevation/6378140*math.Cos(phi)
+	y := 0.99664719*math.Sin(u) + obs.Elevation/6378140*math.Sin(phi)
+
+	h := p.HourAngle * deg
+	delta := p.Declination * deg
+	sinXi := math.Sin(xi * deg)
+	deltaAlpha := math.Atan2(-x*sinXi*math.Sin(h), math.Cos(delta)-x*sinXi*math.Cos(h))
+
+	p.TopocentricRightAscension = p.RightAscension + deltaAlpha/deg
+	p.TopocentricDeclination = math.Atan2((math.Sin(delta)-y*sinXi)*math.Cos(deltaAlpha), math.Cos(delta)-x*sinXi*math.Cos(h)) / deg
+	p.TopocentricHourAngle = p.HourAngle - deltaAlpha/deg
+
+	// Topocentric elevation with refraction
+	deltaPrime := p.TopocentricDeclination * deg
+	hPrime := p.TopocentricHourAngle * deg
+	e0 := math.Asin(math.Sin(phi)*math.Sin(deltaPrime)+math.Cos(phi)*math.Cos(deltaPrime)*math.Cos(hPrime)) / deg
+	p.TrueElevation = e0
+	p.Elevation = e0 + Refraction(e0, obs.Pressure, obs.Temperature)
+	p.Zenith = 90 - p.Elevation
+
+	gamma := math.Atan2(math.Sin(hPrime), math.Cos(hPrime)*math.Sin(phi)-math.Tan(deltaPrime)*math.Cos(phi)) / deg
+	p.Azimuth = limitDegrees(gamma + 180)
+
+	return p.SPAPosition
+}
+
+// Refraction returns the atmospheric refraction in degrees for a true
+// elevation in degrees, using the formula of the SPA (Sæmundsson). Below the
+// horizon of a rising or setting sun there is no correction.
+func Refraction(elevation float64, pressure float64, temperature float64) float64 {
+	if elevation < -(sunRadius + atmosRefract) {
+		return 0
+	}
+	return pressure / 1010 * 283 / (273 + temperature) * 1.02 / (60 * math.Tan((elevation+10.3/(elevation+5.11))*deg))
+}
+
+// HorizonDip returns how far the horizon of an observer at the given
+// elevation in meters lies below the astronomical horizon, in degrees and
+// including refraction along the line of sight.
+func HorizonDip(elevation float64) float64 {
+	if elevation <= 0 {
+		return 0
+	}
+	return 2.076 * math.Sqrt(elevation) / 60
+}
+
+// SunRiseTransitSetSPA computes sunrise, sun transit and sunset around the
+// given local noon with the SPA. Rise and set are zero during polar day and
+// polar night. The observer elevation lowers the horizon by HorizonDip, the
+// paper itself ignores it. Pressure and temperature don't affect the result,
+// the standard refraction at the horizon is used.
+func SunRiseTransitSetSPA(noon time.Time, obs Observer) (time.Time, time.Time, time.Time) {
+	utc := noon.UTC()
+	day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+
+	rise, transit, set := riseTransitSet(day, obs)
+
+	// Far from the timezone meridian the transit can fall on a neighbouring UT day
+	if offset := transit.Sub(noon); offset > 12*time.Hour {
+		rise, transit, set = riseTransitSet(day.AddDate(0, 0, -1), obs)
+	} else if offset < -12*time.Hour {
+		rise, transit, set = riseTransitSet(day.AddDate(0, 0, 1), obs)
+	}
+
+	return rise, transit, set
+}
+
+// riseTransitSet implements appendix A.2 of the SPA for the UT day starting at day
+func riseTransitSet(day time.Time, obs Observer) (time.Time, time.Time, time.Time) {
+	jd := julianDay(day)
+
+	// Sidereal time at 0h UT and the sun position at 0h TT of the previous, this and the next day
+	nu := geocentricSun(jd, obs.DeltaT).siderealTime
+	var alpha, delta [3]float64
+	for i := range alpha {
+		p := geocentricSun(jd+float64(i-1), 0)
+		alpha[i] = p.RightAscension
+		delta[i] = p.Declination
+	}
+
+	phi := obs.Latitude * deg
+	h0Prime := -(sunRadius + atmosRefract + HorizonDip(obs.Elevation))
+
+	// Approximate transit, rise and set as fractions of the day
+	transit := limitZeroToOne((alpha[1] - obs.Longitude - nu) / 360)
+	m := []float64{transit}
+
+	arg := (math.Sin(h0Prime*deg) - math.Sin(phi)*math.Sin(delta[1]*deg)) / (math.Cos(phi) * math.Cos(delta[1]*deg))
+	polar := math.Abs(arg) > 1
+	if !polar {
+		h0 := math.Acos(arg) / deg / 360
+		// Rise and set may fall on the neighbouring UT days, the interpolation covers them
+		m = append(m, transit-h0, transit+h0)
+	}
+
+	events := make([]float64, len(m))
+	for i, mi := range m {
+		nuI := nu + 360.985647*mi
+		n := mi + obs.DeltaT/86400
+		alphaPrime := interpolate(alpha, n)
+		deltaPrime := interpolate(delta, n) * deg
+		hPrime := limitDegrees180(nuI+obs.Longitude-alphaPrime) * deg
+		h := math.Asin(math.Sin(phi)*math.Sin(deltaPrime)+math.Cos(phi)*math.Cos(deltaPrime)*math.Cos(hPrime)) / deg
+
+		if i == 0 {
+			events[i] = mi - hPrime/deg/360
+		} else {
+			events[i] = mi + (h-h0Prime)/(360*math.Cos(deltaPrime)*math.Cos(phi)*math.Sin(hPrime))
+		}
+	}
+
+	at := func(fraction float64) time.Time {
+		return day.Add(time.Duration(fraction * 24 * float64(time.Hour)))
+	}
+
+	if polar {
+		return time.Time{}, at(events[0]), time.Time{}
+	}
+	return at(events[1]), at(events[0]), at(events[2])
+}
+
+// interpolate the sun coordinates of three consecutive days at n days after
+// the middle one
+func interpolate(values [3]float64, n float64) float64 {
+	a := values[1] - values[0]
+	b := values[2] - values[1]
+	if math.Abs(a) >= 2 {
+		a = limitZeroToOne(a)
+	}
+	if math.Abs(b) >= 2 {
+		b = limitZeroToOne(b)
+	}
+	return values[1] + n*(a+b+(b-a)*n)/2
+}
+
+// geocentric holds the geocentric sun position and the sidereal time
+type geocentric struct {
+	SPAPosition
+	siderealTime float64 // Apparent sidereal time at Greenwich in degrees
+}
+
+// geocentricSun computes the geocentric sun position for the Julian day jd
+// with the given ΔT in seconds.
+func geocentricSun(jd float64, deltaT float64) geocentric {
+	var p geocentric
+	p.JulianDay = jd
+
+	jc := (jd - 2451545) / 36525
+	jde := jd + deltaT/86400
+	jce := (jde - 2451545) / 36525
+	jme := jce / 10
+
+	// Earth heliocentric longitude, latitude and radius vector
+	p.L = limitDegrees(earthValue(lTerms, jme) / deg)
+	p.B = earthValue(bTerms, jme) / deg
+	p.R = earthValue(rTerms, jme)
+
+	// Geocentric longitude and latitude
+	theta := limitDegrees(p.L + 180)
+	beta := -p.B
+
+	// Nutation in longitude and obliquity
+	x := [5]float64{
+		297.85036 + 445267.111480*jce - 0.0019142*jce*jce + jce*jce*jce/189474,
+		357.52772 + 35999.050340*jce - 0.0001603*jce*jce - jce*jce*jce/300000,
+		134.96298 + 477198.867398*jce + 0.0086972*jce*jce + jce*jce*jce/56250,
+		93.27191 + 483202.017538*jce - 0.0036825*jce*jce + jce*jce*jce/327270,
+		125.04452 - 1934.136261*jce + 0.0020708*jce*jce + jce*jce*jce/450000,
+	}
+	for i, y := range yTerms {
+		arg := 0.0
+		for j := range x {
+			arg += x[j] * y[j]
+		}
+		arg *= deg
+		p.DeltaPsi += (peTerms[i][0] + peTerms[i][1]*jce) * math.Sin(arg)
+		p.DeltaEpsilon += (peTerms[i][2] + peTerms[i][3]*jce) * math.Cos(arg)
+	}
+	p.DeltaPsi /= 36000000
+	p.DeltaEpsilon /= 36000000
+
+	// True obliquity of the ecliptic
+	u := jme / 10
+	epsilon0 := 84381.448 + u*(-4680.93+u*(-1.55+u*(1999.25+u*(-51.38+u*(-249.67+u*(-39.05+u*(7.12+u*(27.87+u*(5.79+u*2.45)))))))))
+	p.Epsilon = epsilon0/3600 + p.DeltaEpsilon
+
+	// Apparent sun longitude with aberration correction
+	deltaTau := -20.4898 / (3600 * p.R)
+	p.Lambda = theta + p.DeltaPsi + deltaTau
+
+	// Apparent sidereal time at Greenwich
+	nu0 := limitDegrees(280.46061837 + 360.98564736629*(jd-2451545) + 0.000387933*jc*jc - jc*jc*jc/38710000)
+	p.siderealTime = nu0 + p.DeltaPsi*math.Cos(p.Epsilon*deg)
+
+	// Geocentric right ascension and declination
+	lambda := p.Lambda * deg
+	epsilon := p.Epsilon * deg
+	p.RightAscension = limitDegrees(math.Atan2(math.Sin(lambda)*math.Cos(epsilon)-math.Tan(beta*deg)*math.Sin(epsilon), math.Cos(lambda)) / deg)
+	p.Declination = math.Asin(math.Sin(beta*deg)*math.Cos(epsilon)+math.Cos(beta*deg)*math.Sin(epsilon)*math.Sin(lambda)) / deg
+
+	return p
+}
+
+// earthValue sums the periodic terms of one heliocentric quantity
+func earthValue(terms [][][3]float64, jme float64) float64 {
+	value := 0.0
+	for i := len(terms) - 1; i >= 0; i-- {
+		sum := 0.0
+		for _, term := range terms[i] {
+			sum += term[0] * math.Cos(term[1]+term[2]*jme)
+		}
+		value = value*jme + sum
+	}
+	return value / 1e8
+}
+
+// julianDay returns the Julian day of t
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+// Degrees to radians
+const deg = math.Pi / 180
+
+// limitDegrees limits an angle to [0, 360)
+func limitDegrees(degrees float64) float64 {
+	limited := math.Mod(degrees, 360)
+	if limited < 0 {
+		limited += 360
+	}
+	return limited
+}
+
+// limitDegrees180 limits an angle to [-180, 180]
+func limitDegrees180(degrees float64) float64 {
+	limited := limitDegrees(degrees)
+	if limited > 180 {
+		limited -= 360
+	}
+	return limited
+}
+
+// limitZeroToOne keeps the fractional part of value in [0, 1)
+func limitZeroToOne(value float64) float64 {
+	return value - math.Floor(value)
+}
@@ -0,0 +1,111 @@
+package astro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/stretchr/testify/assert"
+)
+
+// Example from table A5.1 of the SPA paper (Reda and Andreas, 2004)
+var spaReferenceTime = time.Date(2003, 10, 17, 12, 30, 30, 0, time.FixedZone("MST", -7*3600))
+
+var spaReferenceObserver = astro.Observer{
+	Latitude:    39.742476,
+	Longitude:   -105.1786,
+	Elevation:   1830.14,
+	Pressure:    820,
+	Temperature: 11,
+	DeltaT:      67,
+}
+
+func TestSolarPositionSPA(t *testing.T) {
+	p := astro.SolarPositionSPA(spaReferenceTime, spaReferenceObserver)
+
+	assert.InDelta(t, 2452930.312847, p.JulianDay, 1e-6)
+	assert.InDelta(t, 24.0182616917, p.L, 1e-8)
+	assert.InDelta(t, -0.0001011219, p.B, 1e-9)
+	assert.InDelta(t, 0.9965422974, p.R, 1e-9)
+	assert.InDelta(t, -0.00399840, p.DeltaPsi, 1e-7)
+	assert.InDelta(t, 0.00166657, p.DeltaEpsilon, 1e-7)
+	assert.InDelta(t, 23.440465, p.Epsilon, 1e-6)
+	assert.InDelta(t, 204.0085519281, p.Lambda, 1e-8)
+	assert.InDelta(t, 202.22741, p.RightAscension, 1e-5)
+	assert.InDelta(t, -9.31434, p.Declination, 1e-5)
+	assert.InDelta(t, 11.105900, p.HourAngle, 1e-5)
+	assert.InDelta(t, 202.22704, p.TopocentricRightAscension, 1e-5)
+	assert.InDelta(t, -9.316179, p.TopocentricDeclination, 1e-6)
+	// The paper rounds H' inconsistently with H and Δα
+	assert.InDelta(t, 11.10629, p.TopocentricHourAngle, 3e-5)
+	assert.InDelta(t, 39.872046, p.TrueElevation, 1e-6)
+	assert.InDelta(t, 50.11162, p.Zenith, 1e-5)
+	assert.InDelta(t, 194.34024, p.Azimuth, 1e-5)
+}
+
+func TestSunRiseTransitSetSPA(t *testing.T) {
+	zone := spaReferenceTime.Location()
+	noon := time.Date(2003, 10, 17, 12, 0, 0, 0, zone)
+
+	// The paper doesn't lower the horizon for the observer elevation
+	observer := spaReferenceObserver
+	observer.Elevation = 0
+
+	rise, transit, set := astro.SunRiseTransitSetSPA(noon, observer)
+
+	assert.WithinDuration(t, time.Date(2003, 10, 17, 6, 12, 43, 0, zone), rise, time.Second)
+	assert.WithinDuration(t, time.Date(2003, 10, 17, 11, 46, 4, 0, zone), transit, time.Second)
+	assert.True(t, set.After(transit))
+
+	// The paper limits all events to the UT day, so in Colorado its sunset
+	// is the one of the previous local evening
+	_, _, set = astro.SunRiseTransitSetSPA(noon.AddDate(0, 0, -1), observer)
+	assert.WithinDuration(t, time.Date(2003, 10, 16, 17, 20, 19, 0, zone), set, time.Second)
+}
+
+func TestSunRiseTransitSetSPAElevation(t *testing.T) {
+	noon := time.Date(2003, 10, 17, 12, 0, 0, 0, spaReferenceTime.Location())
+	seaLevel := spaReferenceObserver
+	seaLevel.Elevation = 0
+
+	rise, transit, set := astro.SunRiseTransitSetSPA(noon, seaLevel)
+	elevatedRise, elevatedTransit, elevatedSet := astro.SunRiseTransitSetSPA(noon, spaReferenceObserver)
+
+	// A horizon 1.5° lower makes the day longer by about 8 minutes on both ends
+	assert.Equal(t, transit, elevatedTransit)
+	assert.InDelta(t, 8*time.Minute, rise.Sub(elevatedRise), float64(time.Minute))
+	assert.InDelta(t, 8*time.Minute, elevatedSet.Sub(set), float64(time.Minute))
+}
+
+func TestSunRiseTransitSetSPAFarFromMeridian(t *testing.T) {
+	// Kiritimati is at 157° west but uses UTC+14, its local days are far from the UT days
+	zone := time.FixedZone("LINT", 14*3600)
+	observer := astro.NewObserver(1.87, -157.43)
+
+	for day := 1; day <= 3; day++ {
+		noon := time.Date(2024, 3, day, 12, 0, 0, 0, zone)
+		rise, transit, set := astro.SunRiseTransitSetSPA(noon, observer)
+
+		assert.Equal(t, day, rise.In(zone).Day())
+		assert.Equal(t, day, transit.In(zone).Day())
+		assert.Equal(t, day, set.In(zone).Day())
+		assert.True(t, rise.Before(transit) && transit.Before(set))
+	}
+}
+
+func TestSunRiseTransitSetSPAPolar(t *testing.T) {
+	observer := astro.NewObserver(78.22, 15.65)
+
+	// Polar night in Longyearbyen
+	rise, transit, set := astro.SunRiseTransitSetSPA(time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), observer)
+	assert.True(t, rise.IsZero())
+	assert.False(t, transit.IsZero())
+	assert.True(t, set.IsZero())
+}
+
+func TestDeltaT(t *testing.T) {
+	// Observed values from the IERS
+	assert.InDelta(t, 63.8, astro.DeltaT(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)), 0.5)
+	assert.InDelta(t, 69.4, astro.DeltaT(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), 5)
+	assert.InDelta(t, 29.1, astro.DeltaT(time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)), 0.5)
+}
@@ -0,0 +1,150 @@
+// Package gazetteer resolves place names to coordinates and timezones with an
+// embedded list of cities, without any network access.
+//
+// cities.tsv holds the columns name, asciiname, latitude, longitude, country
+// code, population and timezone of the GeoNames cities dumps, in that order.
+// The bundled file is a selection of only about 280 major cities, generate.sh
+// replaces it by a full GeoNames extract like cities15000:
+//
+//	go generate ./pkg/gazetteer
+//
+// The GeoNames data is licensed under CC BY 4.0, the first line holds the
+// attribution.
+package gazetteer
+
+//go:generate sh generate.sh
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed cities.tsv
+var citiesTSV string
+
+// Place is a city of the gazetteer
+type Place struct {
+	Name       string
+	Country    string // ISO 3166 alpha-2 country code
+	Latitude   float64
+	Longitude  float64
+	Population int
+	Timezone   string // IANA timezone
+}
+
+// ErrNotFound is returned for names the gazetteer doesn't know
+var ErrNotFound = errors.New("unknown place")
+
+var (
+	loadOnce sync.Once
+	places   map[string][]Place // By lower case name and ASCII name, most populous first
+	size     int                // Number of places
+	loadErr  error
+)
+
+// load parses the embedded cities once
+func load() error {
+	loadOnce.Do(func() {
+		places, size, loadErr = parse(citiesTSV)
+	})
+	return loadErr
+}
+
+// Size returns the number of places in the gazetteer
+func Size() int {
+	if load() != nil {
+		return 0
+	}
+	return size
+}
+
+// Lookup finds a place by its name, optionally followed by a comma and the
+// country code, e.g. "Zurich" or "Paris, US". Names are matched without
+// regard to case and also in their ASCII spelling. Of several places with the
+// same name the most populous one is returned.
+func Lookup(query string) (Place, error) {
+	if err := load(); err != nil {
+		return Place{}, err
+	}
+
+	name, country := strings.TrimSpace(query), ""
+	if i := strings.LastIndex(name, ","); i >= 0 {
+		name, country = strings.TrimSpace(name[:i]), strings.ToUpper(strings.TrimSpace(name[i+1:]))
+		if len(country) != 2 {
+			return Place{}, fmt.Errorf("invalid country code of place %q: %s", query, country)
+		}
+	}
+	if name == "" {
+		return Place{}, fmt.Errorf("place without name")
+	}
+
+	for _, place := range places[strings.ToLower(name)] {
+		if country == "" || place.Country == country {
+			return place, nil
+		}
+	}
+	return Place{}, fmt.Errorf("%w: %s, the offline list only holds %d major cities", ErrNotFound, query, size)
+}
+
+// parse reads the tab separated cities, lines starting with # are comments.
+// Returns the index by name and the number of places.
+func parse(tsv string) (map[string][]Place, int, error) {
+	index := map[string][]Place{}
+	count := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(tsv))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		columns := strings.Split(text, "\t")
+		if len(columns) != 7 {
+			return nil, 0, fmt.Errorf("gazetteer line %d: expected 7 columns, got %d", line, len(columns))
+		}
+		latitude, err := strconv.ParseFloat(columns[2], 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("gazetteer line %d: invalid latitude: %v", line, err)
+		}
+		longitude, err := strconv.ParseFloat(columns[3], 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("gazetteer line %d: invalid longitude: %v", line, err)
+		}
+		population, err := strconv.Atoi(columns[5])
+		if err != nil {
+			return nil, 0, fmt.Errorf("gazetteer line %d: invalid population: %v", line, err)
+		}
+
+		place := Place{
+			Name:       columns[0],
+			Country:    columns[4],
+			Latitude:   latitude,
+			Longitude:  longitude,
+			Population: population,
+			Timezone:   columns[6],
+		}
+		name, ascii := strings.ToLower(columns[0]), strings.ToLower(columns[1])
+		index[name] = append(index[name], place)
+		if ascii != name {
+			index[ascii] = append(index[ascii], place)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	for _, candidates := range index {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Population > candidates[j].Population
+		})
+	}
+	return index, count, nil
+}
@@ -0,0 +1,57 @@
+package gazetteer_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/gazetteer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		query    string
+		country  string
+		latitude float64
+		timezone string
+	}{
+		{"Zurich", "CH", 47.37, "Europe/Zurich"},
+		{"zürich", "CH", 47.37, "Europe/Zurich"},
+		{" Berlin, de ", "DE", 52.52, "Europe/Berlin"},
+		// Of several places the most populous one, unless the country is given
+		{"Paris", "FR", 48.85, "Europe/Paris"},
+		{"Paris, US", "US", 33.66, "America/Chicago"},
+		{"London, CA", "CA", 42.98, "America/Toronto"},
+		{"Cordoba", "AR", -31.41, "America/Argentina/Cordoba"},
+	}
+	for _, tc := range tests {
+		place, err := gazetteer.Lookup(tc.query)
+		if assert.NoError(t, err, tc.query) {
+			assert.Equal(t, tc.country, place.Country, tc.query)
+			assert.InDelta(t, tc.latitude, place.Latitude, 0.01, tc.query)
+			assert.Equal(t, tc.timezone, place.Timezone, tc.query)
+		}
+	}
+
+	_, err := gazetteer.Lookup("Atlantis")
+	assert.ErrorIs(t, err, gazetteer.ErrNotFound)
+	assert.EqualError(t, err, fmt.Sprintf("unknown place: Atlantis, the offline list only holds %d major cities", gazetteer.Size()))
+	assert.Greater(t, gazetteer.Size(), 250)
+	_, err = gazetteer.Lookup("Berlin, US")
+	assert.ErrorIs(t, err, gazetteer.ErrNotFound)
+	_, err = gazetteer.Lookup("Berlin, Germany")
+	assert.EqualError(t, err, `invalid country code of place "Berlin, Germany": GERMANY`)
+}
+
+func TestLookupTimezones(t *testing.T) {
+	// Places with recently renamed or remote timezones load in Go
+	for _, query := range []string{"Kyiv", "Longyearbyen", "Nuuk", "McMurdo Station", "St. John's", "Ushuaia"} {
+		place, err := gazetteer.Lookup(query)
+		if assert.NoError(t, err, query) {
+			_, err = time.LoadLocation(place.Timezone)
+			assert.NoError(t, err, query)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+#!/bin/sh
+# Regenerates cities.tsv from a GeoNames cities dump, by default cities15000
+# with all places of more than 15000 inhabitants. Other dumps like cities5000
+# can be given as argument. Needs curl and unzip.
+set -eu
+
+dump=${1:-cities15000}
+dir=$(dirname "$0")
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+curl -fsSL -o "$tmp/$dump.zip" "https://download.geonames.org/export/dump/$dump.zip"
+unzip -q -d "$tmp" "$tmp/$dump.zip"
+
+# Columns name, asciiname, latitude, longitude, country code, population and timezone
+{
+	echo "# Data: GeoNames (geonames.org), CC BY 4.0 (creativecommons.org/licenses/by/4.0)"
+	printf '# name\tasciiname\tlatitude\tlongitude\tcountry\tpopulation\ttimezone\n'
+	cut -f 2,3,5,6,9,15,18 "$tmp/$dump.txt"
+} >"$dir/cities.tsv"
@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	// Embed the IANA timezone database, Grafana hosts don't always ship one
+	_ "time/tzdata"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
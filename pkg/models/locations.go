@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Location ist ein benannter Standort der Datenquelle, auf den sich Abfragen
+// mit seinem Namen beziehen.
+type Location struct {
+	Name      string   `json:"name"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Elevation *float64 `json:"elevation"` // Höhe über dem Meeresspiegel in Metern, sonst die der Datenquelle
+	Timezone  string   `json:"timezone"`  // IANA Zeitzone, sonst die der Abfrage
+}
+
+// Validate prüft die Angaben des Standorts.
+func (l Location) Validate() error {
+	if l.Name == "" {
+		return fmt.Errorf("location without name")
+	}
+	if l.Latitude < -90 || l.Latitude > 90 {
+		return fmt.Errorf("location %s: latitude not in range -90 to +90: %f", l.Name, l.Latitude)
+	}
+	if l.Longitude < -180 || l.Longitude > 180 {
+		return fmt.Errorf("location %s: longitude not in range -180 to +180: %f", l.Name, l.Longitude)
+	}
+	if l.Timezone != "" {
+		if _, err := LoadLocation(l.Timezone); err != nil {
+			return fmt.Errorf("location %s: unknown timezone: %s", l.Name, l.Timezone)
+		}
+	}
+	return nil
+}
+
+// ValidateLocations prüft alle Standorte und die Eindeutigkeit ihrer Namen.
+func ValidateLocations(locations []Location) error {
+	names := map[string]bool{}
+	for _, location := range locations {
+		if err := location.Validate(); err != nil {
+			return err
+		}
+		if names[location.Name] {
+			return fmt.Errorf("duplicate location: %s", location.Name)
+		}
+		names[location.Name] = true
+	}
+	return nil
+}
+
+// FindLocation sucht einen Standort nach seinem Namen.
+func FindLocation(locations []Location, name string) (Location, bool) {
+	for _, location := range locations {
+		if location.Name == name {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
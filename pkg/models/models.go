@@ -4,6 +4,7 @@ package models
 type SunAndMoonQuery struct {
 	Latitude  *float64  `json:"latitude"`  // Latitude als optionaler Wert
 	Longitude *float64  `json:"longitude"` // Longitude als optionaler Wert
+	Timezone  *string   `json:"timezone"`  // IANA Zeitzone als optionaler Wert
 	Target    *[]string `json:"target"`    // Zielmetriken oder Annotationen
 }
 
@@ -19,13 +20,27 @@ type MetricConfig struct {
 	Unit     string
 	Min      float64
 	Decimals int
+	States   []string // Namen der Zustände, der Index entspricht dem Wert
+	Text     bool     // Liefert den Namen des Zustands als Text statt der Zahl
+	Daily    bool     // Liefert einen Wert pro lokalem Tag zu dessen Beginn statt einen pro Intervall
 }
 
 // AnnotationDefinition definiert eine Annotation mit Titel, Text und Tag.
+// Regionen setzen zusätzlich Start und End auf die Schlüssel der Annotationen,
+// die den Zeitraum begrenzen, oder Region, wenn der Zeitraum direkt berechnet
+// wird.
 type AnnotationDefinition struct {
-	Title string
-	Text  string
-	Tag   string
+	Title  string
+	Text   string
+	Tag    string
+	Start  string
+	End    string
+	Region bool
+}
+
+// IsRegion gibt an, ob die Annotation einen Zeitraum statt eines Zeitpunkts beschreibt.
+func (a AnnotationDefinition) IsRegion() bool {
+	return a.Region || a.End != ""
 }
 
 // SunAndMoonMetrics ist eine Map, die alle Metriken definiert.
@@ -82,13 +97,248 @@ var SunAndMoonMetrics = map[string]MetricDefinition{
 	},
 	"sun_maximum_altitude": {
 		Title: "Maximum sun altitude of the day",
-		Text:  "Maximum height of the sun of the day (at solar noon) in degrees (-90 - 90)",
+		Text:  "Maximum height of the sun of the day (at solar noon) in degrees (-90 - 90), negative during polar night",
 		Config: MetricConfig{
 			Unit:     "degree",
+			Min:      -90,
+			Decimals: 1,
+		},
+	},
+	"day_length": {
+		Title: "Day length",
+		Text:  "Time from sunrise to sunset of the day, the whole day during polar day and 0 during polar night",
+		Config: MetricConfig{
+			Unit:     "s",
 			Min:      0,
+			Decimals: 0,
+			Daily:    true,
+		},
+	},
+	"day_length_delta": {
+		Title: "Day length change",
+		Text:  "Difference between the day length and the one of the previous day",
+		Config: MetricConfig{
+			Unit:     "s",
+			Min:      -86400,
+			Decimals: 0,
+			Daily:    true,
+		},
+	},
+	"civil_twilight_duration": {
+		Title: "Civil twilight duration",
+		Text:  "Time of the day with the sun between the horizon and -6 degrees, morning and evening together",
+		Config: MetricConfig{
+			Unit:     "s",
+			Min:      0,
+			Decimals: 0,
+			Daily:    true,
+		},
+	},
+	"nautical_twilight_duration": {
+		Title: "Nautical twilight duration",
+		Text:  "Time of the day with the sun between -6 and -12 degrees, morning and evening together",
+		Config: MetricConfig{
+			Unit:     "s",
+			Min:      0,
+			Decimals: 0,
+			Daily:    true,
+		},
+	},
+	"astronomical_twilight_duration": {
+		Title: "Astronomical twilight duration",
+		Text:  "Time of the day with the sun between -12 and -18 degrees, morning and evening together",
+		Config: MetricConfig{
+			Unit:     "s",
+			Min:      0,
+			Decimals: 0,
+			Daily:    true,
+		},
+	},
+	"clear_sky_ghi": {
+		Title: "Clear-sky GHI",
+		Text:  "Modelled global horizontal irradiance under a cloudless sky in W/m²",
+		Config: MetricConfig{
+			Unit:     "Wm2",
+			Min:      0,
+			Decimals: 0,
+		},
+	},
+	"clear_sky_dni": {
+		Title: "Clear-sky DNI",
+		Text:  "Modelled direct normal irradiance under a cloudless sky in W/m²",
+		Config: MetricConfig{
+			Unit:     "Wm2",
+			Min:      0,
+			Decimals: 0,
+		},
+	},
+	"clear_sky_dhi": {
+		Title: "Clear-sky DHI",
+		Text:  "Modelled diffuse horizontal irradiance under a cloudless sky in W/m²",
+		Config: MetricConfig{
+			Unit:     "Wm2",
+			Min:      0,
+			Decimals: 0,
+		},
+	},
+	"surface_aoi": {
+		Title: "Angle of incidence",
+		Text:  "Angle between the sun and the normal of the surface in degrees, above 90 the sun is behind it (0 - 180)",
+		Config: MetricConfig{
+			Unit:     "degree",
+			Min:      0,
+			Decimals: 1,
+		},
+	},
+	"poa_irradiance": {
+		Title: "Plane-of-array irradiance",
+		Text:  "Modelled irradiance on the surface under a cloudless sky in W/m² (Hay-Davies)",
+		Config: MetricConfig{
+			Unit:     "Wm2",
+			Min:      0,
+			Decimals: 0,
+		},
+	},
+	"pv_expected_power": {
+		Title: "Expected PV power",
+		Text:  "Modelled output of the configured PV arrays under a cloudless sky in kW",
+		Config: MetricConfig{
+			Unit:     "kwatt",
+			Min:      0,
+			Decimals: 2,
+		},
+	},
+	"pv_expected_energy_daily": {
+		Title: "Expected PV energy of the day",
+		Text:  "Modelled yield of the configured PV arrays over the local day under a cloudless sky in kWh",
+		Config: MetricConfig{
+			Unit:     "kwatth",
+			Min:      0,
+			Decimals: 1,
+			Daily:    true,
+		},
+	},
+	"tracker_rotation": {
+		Title: "Tracker rotation",
+		Text:  "Rotation of a single-axis tracker in degrees, positive towards the west for an axis pointing south",
+		Config: MetricConfig{
+			Unit:     "degree",
+			Min:      -90,
 			Decimals: 1,
 		},
 	},
+	"moon_phase": {
+		Title: "Moon phase",
+		Text:  "Position in the lunar cycle, new moon (0.0), first quarter (0.25), full moon (0.5), last quarter (0.75)",
+		Config: MetricConfig{
+			Unit:     "none",
+			Decimals: 2,
+		},
+	},
+	"moon_age": {
+		Title: "Moon age",
+		Text:  "Days since the last new moon (0 - 29.5)",
+		Config: MetricConfig{
+			Unit:     "d",
+			Decimals: 1,
+		},
+	},
+	"moon_waxing": {
+		Title: "Moon waxing",
+		Text:  "1 from new moon to full moon, 0 while the moon is waning",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+			States:   []string{"Waning", "Waxing"},
+		},
+	},
+	"moon_phase_name": {
+		Title: "Moon phase name",
+		Text:  "Name of the moon phase, e.g. Waxing Gibbous",
+		Config: MetricConfig{
+			Unit:     "none",
+			Decimals: 0,
+			States:   []string{"New Moon", "Waxing Crescent", "First Quarter", "Waxing Gibbous", "Full Moon", "Waning Gibbous", "Last Quarter", "Waning Crescent"},
+			Text:     true,
+		},
+	},
+	"moon_above_horizon": {
+		Title: "Moon above horizon",
+		Text:  "1 while the moon is above the horizon, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
+	"sun_horizon_state": {
+		Title: "Sun horizon state",
+		Text:  "Whether the sun rises or sets during the day (0), stays up all day during polar day (1) or stays down during polar night (2)",
+		Config: MetricConfig{
+			Unit:     "none",
+			Decimals: 0,
+			States:   []string{"Rises or sets", "Polar day", "Polar night"},
+			Daily:    true,
+		},
+	},
+	"moon_horizon_state": {
+		Title: "Moon horizon state",
+		Text:  "Whether the moon rises or sets during the day (0), stays above (1) or below the horizon all day (2)",
+		Config: MetricConfig{
+			Unit:     "none",
+			Decimals: 0,
+			States:   []string{"Rises or sets", "Always up", "Always down"},
+			Daily:    true,
+		},
+	},
+	"sun_phase": {
+		Title: "Sun phase",
+		Text:  "Night (0), astronomical (1), nautical (2) or civil twilight (3), daylight (4)",
+		Config: MetricConfig{
+			Unit:     "none",
+			Decimals: 0,
+			States:   []string{"Night", "Astronomical twilight", "Nautical twilight", "Civil twilight", "Daylight"},
+		},
+	},
+	"is_daylight": {
+		Title: "Daylight",
+		Text:  "1 between sunrise and sunset, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
+	"is_civil_twilight": {
+		Title: "Civil twilight",
+		Text:  "1 while the sun is between the horizon and -6 degrees, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
+	"is_nautical_twilight": {
+		Title: "Nautical twilight",
+		Text:  "1 while the sun is between -6 and -12 degrees, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
+	"is_astronomical_twilight": {
+		Title: "Astronomical twilight",
+		Text:  "1 while the sun is between -12 and -18 degrees, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
+	"is_night": {
+		Title: "Night",
+		Text:  "1 while the sun is below -18 degrees, otherwise 0",
+		Config: MetricConfig{
+			Unit:     "bool",
+			Decimals: 0,
+		},
+	},
 }
 
 // SunAndMoonAnnotations ist eine Map, die alle Annotationen definiert.
@@ -98,6 +348,11 @@ var SunAndMoonAnnotations = map[string]AnnotationDefinition{
 		Text:  "Top edge of the sun appears on the horizon",
 		Tag:   "sun",
 	},
+	"sunAltitudeCross": {
+		Title: "Sun altitude crossing",
+		Text:  "Sun passes the altitude set in the query",
+		Tag:   "sun",
+	},
 	"sunriseEnd": {
 		Title: "Sunrise ends",
 		Text:  "Bottom edge of the sun touches the horizon",
@@ -118,6 +373,16 @@ var SunAndMoonAnnotations = map[string]AnnotationDefinition{
 		Text:  "Soft light, best time for photography",
 		Tag:   "sun",
 	},
+	"blueHourEnd": {
+		Title: "Morning blue hour ends",
+		Text:  "Sun rises above -4 degrees, deep blue sky gives way to warm light",
+		Tag:   "sun",
+	},
+	"blueHour": {
+		Title: "Evening blue hour starts",
+		Text:  "Sun sinks below -4 degrees, the sky turns deep blue",
+		Tag:   "sun",
+	},
 	"sunsetStart": {
 		Title: "Sunset starts",
 		Text:  "Bottom edge of the sun touches the horizon",
@@ -173,6 +438,207 @@ var SunAndMoonAnnotations = map[string]AnnotationDefinition{
 		Text:  "Moon disappears below the horizon",
 		Tag:   "moon",
 	},
+	"newMoon": {
+		Title: "New moon",
+		Text:  "Moon is between earth and sun and not visible",
+		Tag:   "moon",
+	},
+	"firstQuarter": {
+		Title: "First quarter",
+		Text:  "Right half of the moon is lit (northern hemisphere)",
+		Tag:   "moon",
+	},
+	"fullMoon": {
+		Title: "Full moon",
+		Text:  "Moon is opposite the sun and fully lit",
+		Tag:   "moon",
+	},
+	"lastQuarter": {
+		Title: "Last quarter",
+		Text:  "Left half of the moon is lit (northern hemisphere)",
+		Tag:   "moon",
+	},
+	"perigee": {
+		Title: "Lunar perigee",
+		Text:  "Moon is closest to earth",
+		Tag:   "moon",
+	},
+	"apogee": {
+		Title: "Lunar apogee",
+		Text:  "Moon is farthest from earth",
+		Tag:   "moon",
+	},
+	"supermoon": {
+		Title: "Supermoon",
+		Text:  "Full or new moon close to perigee",
+		Tag:   "moon",
+	},
+	"micromoon": {
+		Title: "Micromoon",
+		Text:  "Full or new moon close to apogee",
+		Tag:   "moon",
+	},
+	"marchEquinox": {
+		Title: "March equinox",
+		Text:  "Sun crosses the celestial equator northwards, day and night are about equally long",
+		Tag:   "season",
+	},
+	"juneSolstice": {
+		Title: "June solstice",
+		Text:  "Sun reaches its northernmost position",
+		Tag:   "season",
+	},
+	"septemberEquinox": {
+		Title: "September equinox",
+		Text:  "Sun crosses the celestial equator southwards, day and night are about equally long",
+		Tag:   "season",
+	},
+	"decemberSolstice": {
+		Title: "December solstice",
+		Text:  "Sun reaches its southernmost position",
+		Tag:   "season",
+	},
+	"astronomicalSpring": {
+		Title: "Astronomical spring",
+		Text:  "Spring begins at the equinox, in the hemisphere of the location",
+		Tag:   "season",
+	},
+	"astronomicalSummer": {
+		Title: "Astronomical summer",
+		Text:  "Summer begins at the solstice, in the hemisphere of the location",
+		Tag:   "season",
+	},
+	"astronomicalAutumn": {
+		Title: "Astronomical autumn",
+		Text:  "Autumn begins at the equinox, in the hemisphere of the location",
+		Tag:   "season",
+	},
+	"astronomicalWinter": {
+		Title: "Astronomical winter",
+		Text:  "Winter begins at the solstice, in the hemisphere of the location",
+		Tag:   "season",
+	},
+	"meteorologicalSpring": {
+		Title: "Meteorological spring",
+		Text:  "Spring begins on March 1st, September 1st in the southern hemisphere",
+		Tag:   "season",
+	},
+	"meteorologicalSummer": {
+		Title: "Meteorological summer",
+		Text:  "Summer begins on June 1st, December 1st in the southern hemisphere",
+		Tag:   "season",
+	},
+	"meteorologicalAutumn": {
+		Title: "Meteorological autumn",
+		Text:  "Autumn begins on September 1st, March 1st in the southern hemisphere",
+		Tag:   "season",
+	},
+	"meteorologicalWinter": {
+		Title: "Meteorological winter",
+		Text:  "Winter begins on December 1st, June 1st in the southern hemisphere",
+		Tag:   "season",
+	},
+	"solarEclipse": {
+		Title:  "Solar eclipse",
+		Text:   "Moon covers part of the sun, while the sun is above the horizon",
+		Tag:    "eclipse",
+		Region: true,
+	},
+	"polarDay": {
+		Title:  "Polar day",
+		Text:   "Sun stays above the horizon all day long",
+		Tag:    "sun",
+		Region: true,
+	},
+	"polarNight": {
+		Title:  "Polar night",
+		Text:   "Sun stays below the horizon all day long",
+		Tag:    "sun",
+		Region: true,
+	},
+	"lunarEclipse": {
+		Title:  "Lunar eclipse",
+		Text:   "Moon passes through the shadow of the earth, while the moon is above the horizon",
+		Tag:    "eclipse",
+		Region: true,
+	},
+	"morningAstronomicalTwilight": {
+		Title: "Morning astronomical twilight",
+		Text:  "Sun between -18 and -12 degrees before sunrise",
+		Tag:   "sun",
+		Start: "nightEnd",
+		End:   "nauticalDawn",
+	},
+	"morningNauticalTwilight": {
+		Title: "Morning nautical twilight",
+		Text:  "Sun between -12 and -6 degrees before sunrise",
+		Tag:   "sun",
+		Start: "nauticalDawn",
+		End:   "dawn",
+	},
+	"morningCivilTwilight": {
+		Title: "Morning civil twilight",
+		Text:  "Sun between -6 degrees and sunrise",
+		Tag:   "sun",
+		Start: "dawn",
+		End:   "sunrise",
+	},
+	"morningBlueHour": {
+		Title: "Morning blue hour",
+		Text:  "Sun between -6 and -4 degrees before sunrise",
+		Tag:   "sun",
+		Start: "dawn",
+		End:   "blueHourEnd",
+	},
+	"morningGoldenHour": {
+		Title: "Morning golden hour",
+		Text:  "Soft light from sunrise until the sun reaches 6 degrees",
+		Tag:   "sun",
+		Start: "sunrise",
+		End:   "goldenHourEnd",
+	},
+	"eveningGoldenHour": {
+		Title: "Evening golden hour",
+		Text:  "Soft light from 6 degrees until sunset",
+		Tag:   "sun",
+		Start: "goldenHour",
+		End:   "sunset",
+	},
+	"eveningBlueHour": {
+		Title: "Evening blue hour",
+		Text:  "Sun between -4 and -6 degrees after sunset",
+		Tag:   "sun",
+		Start: "blueHour",
+		End:   "dusk",
+	},
+	"eveningCivilTwilight": {
+		Title: "Evening civil twilight",
+		Text:  "Sun between sunset and -6 degrees",
+		Tag:   "sun",
+		Start: "sunset",
+		End:   "dusk",
+	},
+	"eveningNauticalTwilight": {
+		Title: "Evening nautical twilight",
+		Text:  "Sun between -6 and -12 degrees after sunset",
+		Tag:   "sun",
+		Start: "dusk",
+		End:   "nauticalDusk",
+	},
+	"eveningAstronomicalTwilight": {
+		Title: "Evening astronomical twilight",
+		Text:  "Sun between -12 and -18 degrees after sunset",
+		Tag:   "sun",
+		Start: "nauticalDusk",
+		End:   "night",
+	},
+	"fullNight": {
+		Title: "Night",
+		Text:  "Sun below -18 degrees, from night start until night end",
+		Tag:   "sun",
+		Start: "night",
+		End:   "nightEnd",
+	},
 	"noon": {
 		Title: "Noon",
 		Text:  "12 o'clock in the daytime",
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// PlanetTarget ordnet eine Metrik oder Annotation ihrem Planeten zu. Kind ist
+// die Größe der Metrik (z.B. "altitude") bzw. das Ereignis der Annotation
+// (z.B. "Rise").
+type PlanetTarget struct {
+	Planet astro.Planet
+	Kind   string
+}
+
+// PlanetMetrics und PlanetAnnotations enthalten die Schlüssel aller Planeten,
+// Abfragen schlagen ihre Ziele darin exakt nach.
+var (
+	PlanetMetrics     = map[string]PlanetTarget{}
+	PlanetAnnotations = map[string]PlanetTarget{}
+)
+
+// init ergänzt Metriken und Annotationen für jeden Planeten aus astro.Planets,
+// die Schlüssel lauten z.B. "mars_altitude" und "marsRise".
+func init() {
+	for _, planet := range astro.Planets {
+		name := planet.String()
+		title := strings.ToUpper(name[:1]) + name[1:]
+
+		metric := func(kind string, def MetricDefinition) {
+			SunAndMoonMetrics[name+"_"+kind] = def
+			PlanetMetrics[name+"_"+kind] = PlanetTarget{Planet: planet, Kind: kind}
+		}
+		annotation := func(kind string, def AnnotationDefinition) {
+			SunAndMoonAnnotations[name+kind] = def
+			PlanetAnnotations[name+kind] = PlanetTarget{Planet: planet, Kind: kind}
+		}
+
+		metric("altitude", MetricDefinition{
+			Title: title + " altitude",
+			Text:  "Height of " + title + " in degrees (-90 - 90)",
+			Config: MetricConfig{
+				Unit:     "degree",
+				Min:      0,
+				Decimals: 1,
+			},
+		})
+		metric("azimuth", MetricDefinition{
+			Title: title + " azimuth",
+			Text:  "Direction of " + title + " along the horizon in degrees (0 - 360)",
+			Config: MetricConfig{
+				Unit:     "degree",
+				Decimals: 1,
+			},
+		})
+		metric("distance", MetricDefinition{
+			Title: title + " distance",
+			Text:  "Distance to " + title + " in kilometers",
+			Config: MetricConfig{
+				Unit:     "lengthkm",
+				Decimals: 0,
+			},
+		})
+		metric("magnitude", MetricDefinition{
+			Title: title + " magnitude",
+			Text:  "Apparent visual magnitude of " + title + ", lower is brighter",
+			Config: MetricConfig{
+				Unit:     "none",
+				Min:      -5,
+				Decimals: 1,
+			},
+		})
+		metric("elongation", MetricDefinition{
+			Title: title + " elongation",
+			Text:  "Angle between the sun and " + title + " in degrees, positive in the evening sky east of the sun (-180 - 180)",
+			Config: MetricConfig{
+				Unit:     "degree",
+				Min:      -180,
+				Decimals: 1,
+			},
+		})
+
+		annotation("Rise", AnnotationDefinition{
+			Title: title + " rise",
+			Text:  title + " appears on the horizon",
+			Tag:   name,
+		})
+		annotation("Transit", AnnotationDefinition{
+			Title: title + " transit",
+			Text:  title + " crosses the meridian at its highest position",
+			Tag:   name,
+		})
+		annotation("Set", AnnotationDefinition{
+			Title: title + " set",
+			Text:  title + " disappears below the horizon",
+			Tag:   name,
+		})
+	}
+}
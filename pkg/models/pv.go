@@ -0,0 +1,57 @@
+package models
+
+import "fmt"
+
+// PVArray beschreibt ein PV-Modulfeld einer Datenquelle, aus dem die
+// erwartete Leistung bei klarem Himmel berechnet wird.
+type PVArray struct {
+	Name    string   `json:"name"`
+	Kwp     float64  `json:"kwp"`     // Nennleistung in kWp
+	Tilt    float64  `json:"tilt"`    // Neigung in Grad, bei Trackern die der Achse
+	Azimuth *float64 `json:"azimuth"` // Ausrichtung in Grad von Norden, Standard zum Äquator
+	Tracker string   `json:"tracker"` // "fixed" oder "singleAxis"
+
+	TemperatureCoefficient *float64 `json:"temperatureCoefficient"` // Leistungsänderung in %/°C optional
+	Losses                 *float64 `json:"losses"`                 // Systemverluste in % optional
+}
+
+// Validate prüft die Angaben des Modulfelds.
+func (a PVArray) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("PV array without name")
+	}
+	if a.Kwp <= 0 {
+		return fmt.Errorf("PV array %s: kWp must be above 0: %f", a.Name, a.Kwp)
+	}
+	if a.Tilt < 0 || a.Tilt > 90 {
+		return fmt.Errorf("PV array %s: tilt not in range 0 to 90: %f", a.Name, a.Tilt)
+	}
+	if a.Azimuth != nil && (*a.Azimuth < 0 || *a.Azimuth > 360) {
+		return fmt.Errorf("PV array %s: azimuth not in range 0 to 360: %f", a.Name, *a.Azimuth)
+	}
+	if a.Tracker != "" && a.Tracker != "fixed" && a.Tracker != "singleAxis" {
+		return fmt.Errorf("PV array %s: unknown tracker: %s", a.Name, a.Tracker)
+	}
+	if a.TemperatureCoefficient != nil && (*a.TemperatureCoefficient < -2 || *a.TemperatureCoefficient > 0) {
+		return fmt.Errorf("PV array %s: temperature coefficient not in range -2 to 0: %f", a.Name, *a.TemperatureCoefficient)
+	}
+	if a.Losses != nil && (*a.Losses < 0 || *a.Losses >= 100) {
+		return fmt.Errorf("PV array %s: losses not in range 0 to 100: %f", a.Name, *a.Losses)
+	}
+	return nil
+}
+
+// ValidatePVArrays prüft alle Modulfelder und die Eindeutigkeit ihrer Namen.
+func ValidatePVArrays(arrays []PVArray) error {
+	names := map[string]bool{}
+	for _, array := range arrays {
+		if err := array.Validate(); err != nil {
+			return err
+		}
+		if names[array.Name] {
+			return fmt.Errorf("duplicate PV array: %s", array.Name)
+		}
+		names[array.Name] = true
+	}
+	return nil
+}
@@ -8,9 +8,20 @@ import (
 )
 
 // PluginSettings enthält jetzt Latitude und Longitude
-type PluginSettings struct {	
-	Latitude  *float64              `json:"latitude"`  // Latitude optional
-	Longitude *float64              `json:"longitude"` // Longitude optional
+type PluginSettings struct {
+	Latitude  *float64 `json:"latitude"`  // Latitude optional
+	Longitude *float64 `json:"longitude"` // Longitude optional
+	Timezone  *string  `json:"timezone"`  // IANA Zeitzone optional
+
+	Elevation   *float64 `json:"elevation"`   // Höhe über dem Meeresspiegel in Metern optional
+	Pressure    *float64 `json:"pressure"`    // Luftdruck in mbar optional
+	Temperature *float64 `json:"temperature"` // Lufttemperatur in °C optional
+
+	LinkeTurbidity *float64 `json:"linkeTurbidity"` // Linke-Trübung des klaren Himmels optional
+
+	PVArrays []PVArray `json:"pvArrays"` // PV-Modulfelder für die erwartete Leistung optional
+
+	Locations []Location `json:"locations"` // Benannte Standorte für die Abfragen optional
 }
 
 // LoadPluginSettings lädt die Plugin-Einstellungen und validiert Latitude/Longitude
@@ -31,5 +42,35 @@ func LoadPluginSettings(source backend.DataSourceInstanceSettings) (*PluginSetti
 		return nil, fmt.Errorf("Longitude not in range -360 to +360: %f", *settings.Longitude)
 	}
 
+	// Validierung der Zeitzone
+	if settings.Timezone != nil && *settings.Timezone != "" {
+		if _, err := LoadLocation(*settings.Timezone); err != nil {
+			return nil, fmt.Errorf("Unknown timezone: %s", *settings.Timezone)
+		}
+	}
+
+	// Validierung der Atmosphäre
+	if settings.Pressure != nil && *settings.Pressure <= 0 {
+		return nil, fmt.Errorf("Pressure must be above 0 mbar: %f", *settings.Pressure)
+	}
+
+	if settings.Temperature != nil && *settings.Temperature <= -273.15 {
+		return nil, fmt.Errorf("Temperature must be above -273.15 °C: %f", *settings.Temperature)
+	}
+
+	if settings.LinkeTurbidity != nil && (*settings.LinkeTurbidity < 1 || *settings.LinkeTurbidity > 10) {
+		return nil, fmt.Errorf("Linke turbidity not in range 1 to 10: %f", *settings.LinkeTurbidity)
+	}
+
+	// Validierung der PV-Modulfelder
+	if err := ValidatePVArrays(settings.PVArrays); err != nil {
+		return nil, err
+	}
+
+	// Validierung der Standorte
+	if err := ValidateLocations(settings.Locations); err != nil {
+		return nil, err
+	}
+
 	return &settings, nil
 }
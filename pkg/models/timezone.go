@@ -0,0 +1,16 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// LoadLocation lädt eine IANA Zeitzone wie time.LoadLocation, akzeptiert aber
+// auch das kleingeschriebene "utc" von Grafana. Einstellungen, Standorte und
+// Abfragen verwenden dieselbe Prüfung.
+func LoadLocation(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "utc") {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// How far back lastEventTime looks for an event, covers days without moonrise
+const alertLookback = 3
+
+// fromAlert reports whether the request was sent by Grafana alerting
+func fromAlert(req *backend.QueryDataRequest) bool {
+	return req.Headers["FromAlert"] == "true"
+}
+
+// numericFrame builds a frame holding a single labelled number, the shape
+// alert rules and server side expressions handle without a reduce step. A nil
+// value is returned as null so the rule reports no data.
+func numericFrame(name string, value *float64, labels data.Labels, config *data.FieldConfig) *data.Frame {
+	frame := data.NewFrame(name,
+		data.NewField("Value", labels, []*float64{value}).SetConfig(config),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type:        data.FrameTypeNumericMulti,
+		TypeVersion: data.FrameTypeVersion{0, 1},
+	}
+	return frame
+}
+
+// lastEventTime returns the latest occurrence of an annotation at or before
+// at, which must be in the resolved timezone. Regions count from their start.
+// Returns the zero time if the event didn't happen within the lookback, which
+// is alertLookback days or the lookback of the event finder, searched back in
+// its steps.
+func lastEventTime(annotation string, def models.AnnotationDefinition, at time.Time, obs observer, scans planetScans) time.Time {
+	if finder, ok := findRangeEvent(annotation, scans); ok {
+		earliest := at.Add(-finder.lookback)
+		step := finder.step
+		if step == 0 {
+			step = finder.lookback
+		}
+		// Search backwards window by window, the range excludes its end, at itself still counts
+		for to := at.Add(time.Nanosecond); to.After(earliest); to = to.Add(-step) {
+			from := to.Add(-step)
+			if from.Before(earliest) {
+				from = earliest
+			}
+			if events := finder.find(from, to, obs); len(events) > 0 {
+				return events[len(events)-1].Time
+			}
+		}
+		return time.Time{}
+	}
+
+	today := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	for i := 0; i <= alertLookback; i++ {
+		day := today.AddDate(0, 0, -i)
+
+		var event time.Time
+		if def.End != "" {
+			event, _ = regionTimes(def, day, obs)
+		} else {
+			event = eventTime(annotation, day, obs)
+		}
+
+		if !event.IsZero() && !event.After(at) {
+			return event
+		}
+	}
+
+	return time.Time{}
+}
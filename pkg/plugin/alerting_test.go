@@ -0,0 +1,106 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataFromAlert(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	// 20:00 in Berlin, a while after sunset
+	to := time.Date(2024, 10, 15, 18, 0, 0, 0, time.UTC)
+	req := &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"timezone": "Europe/Berlin", "target": ["sun_altitude", "is_night", "sunset"]}`),
+				// Alert rules often evaluate windows shorter than the default interval
+				TimeRange: backend.TimeRange{
+					From: to.Add(-time.Minute),
+					To:   to,
+				},
+				Interval: time.Minute,
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 3)
+
+	for _, frame := range frames {
+		assert.Equal(t, data.FrameTypeNumericMulti, frame.Meta.Type)
+		assert.Len(t, frame.Fields, 1)
+		assert.Equal(t, 1, frame.Rows())
+	}
+
+	// Alerting reports the same values a regular query returns for the instant
+	regular, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(`{"timezone": "Europe/Berlin", "target": ["sun_altitude"]}`),
+				TimeRange: backend.TimeRange{From: to, To: to.Add(time.Second)},
+				Interval:  time.Second,
+			},
+			{
+				RefID: "B",
+				JSON:  []byte(`{"timezone": "Europe/Berlin", "target": ["sunset"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 10, 14, 22, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 10, 15, 22, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	altitude := frames[0].Fields[0]
+	assert.Equal(t, data.Labels{"target": "sun_altitude"}, altitude.Labels)
+	assert.Equal(t, 1, regular.Responses["A"].Frames[0].Rows())
+	assert.InDelta(t, regular.Responses["A"].Frames[0].Fields[1].At(0), *altitude.At(0).(*float64), 1e-9)
+
+	sunset := regular.Responses["B"].Frames[0].Fields[0].At(0).(time.Time)
+	since := frames[2].Fields[0]
+	assert.Equal(t, data.Labels{"target": "sunset"}, since.Labels)
+	assert.InDelta(t, to.Sub(sunset).Seconds(), *since.At(0).(*float64), 1)
+}
+
+func TestQueryDataTimeSeriesLabels(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"target": ["sun_altitude"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 10, 16, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	value := resp.Responses["A"].Frames[0].Fields[1]
+	assert.Equal(t, data.Labels{"target": "sun_altitude"}, value.Labels)
+	assert.Equal(t, "Sun altitude", value.Config.DisplayNameFromDS)
+}
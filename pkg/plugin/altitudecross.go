@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+)
+
+// Directions in which the sun crosses an altitude
+const (
+	directionRising  = "rising"
+	directionSetting = "setting"
+)
+
+// altitudeCross is the sun altitude and the direction of the
+// sunAltitudeCross annotation
+type altitudeCross struct {
+	Altitude  *float64 // Degrees above the horizon, negative below it
+	Direction string   // "rising" or "setting", empty for both
+}
+
+// validate checks the altitude and the direction
+func (c altitudeCross) validate() error {
+	switch c.Direction {
+	case "", directionRising, directionSetting:
+	default:
+		return fmt.Errorf("unknown sun direction: %s", c.Direction)
+	}
+
+	if c.Altitude != nil && (*c.Altitude < -90 || *c.Altitude > 90) {
+		return fmt.Errorf("sun altitude not in range -90 to 90: %g", *c.Altitude)
+	}
+	return nil
+}
+
+// checkAltitudeCross reports the sunAltitudeCross annotation queried without
+// an altitude
+func checkAltitudeCross(annotations []string, obs observer) error {
+	if obs.AltitudeCross.Altitude != nil {
+		return nil
+	}
+	for _, target := range annotations {
+		if annotation, _, _ := parseAnnotationTarget(target); annotation == "sunAltitudeCross" {
+			return fmt.Errorf("no sun altitude set for %s", target)
+		}
+	}
+	return nil
+}
+
+// altitudeCrossEvents finds the times the sun passes the altitude of the
+// observer in its direction. Like the twilight angles the altitude is the
+// true one, lowered by the horizon dip. The sun rises through the altitude
+// between the solar midnight and the solar noon of a day and sets through it
+// in the twelve hours after the noon.
+func altitudeCrossEvents(from time.Time, to time.Time, obs observer) []event {
+	if obs.AltitudeCross.Altitude == nil {
+		return []event{}
+	}
+	altitude := *obs.AltitudeCross.Altitude
+	angle := altitude - obs.dip()
+
+	events := []event{}
+	// Start a day early, the setting of the previous day can fall after midnight
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()).AddDate(0, 0, -1)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, day.Location())
+		_, solarNoon, _ := sunRiseNoonSet(noon, obs)
+
+		crossings := []event{}
+		if obs.AltitudeCross.Direction != directionSetting {
+			rise := altitudeCrossing(solarNoon.Add(-12*time.Hour), solarNoon, obs, angle)
+			crossings = append(crossings, event{Time: rise, Text: fmt.Sprintf("Sun rises through %g°", altitude)})
+		}
+		if obs.AltitudeCross.Direction != directionRising {
+			set := altitudeCrossing(solarNoon, solarNoon.Add(12*time.Hour), obs, angle)
+			crossings = append(crossings, event{Time: set, Text: fmt.Sprintf("Sun sets through %g°", altitude)})
+		}
+
+		for _, crossing := range crossings {
+			if !crossing.Time.IsZero() && !crossing.Time.Before(from) && crossing.Time.Before(to) {
+				crossing.Time = crossing.Time.In(from.Location())
+				events = append(events, crossing)
+			}
+		}
+	}
+
+	sortEvents(events)
+	return events
+}
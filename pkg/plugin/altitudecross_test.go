@@ -0,0 +1,60 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataSunAltitudeCross(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Vienna"}
+
+	days := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 22, 22, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"sunAltitude": "-0.833", "target": ["sunAltitudeCross", "sunrise", "sunset"]}`), TimeRange: days},
+			{RefID: "B", JSON: []byte(`{"sunAltitude": "10", "sunDirection": "setting", "target": ["sunAltitudeCross"]}`), TimeRange: days},
+			{RefID: "C", JSON: []byte(`{"target": ["sunAltitudeCross"]}`), TimeRange: days},
+			{RefID: "D", JSON: []byte(`{"sunAltitude": "10", "sunDirection": "sideways", "target": ["sunAltitudeCross"]}`), TimeRange: days},
+			{RefID: "E", JSON: []byte(`{"sunAltitude": "70", "target": ["sunAltitudeCross"]}`), TimeRange: days},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// At the sunrise angle the crossings are sunrise and sunset, up to the
+	// minute suncalc approximates its rise and set times to
+	frames := resp.Responses["A"].Frames
+	cross, sunrise, sunset := frames[0], frames[1], frames[2]
+	assert.Equal(t, 4, cross.Rows())
+	for i := 0; i < 2; i++ {
+		assert.WithinDuration(t, sunrise.Fields[0].At(i).(time.Time), cross.Fields[0].At(2*i).(time.Time), 2*time.Minute)
+		assert.WithinDuration(t, sunset.Fields[0].At(i).(time.Time), cross.Fields[0].At(2*i+1).(time.Time), 2*time.Minute)
+	}
+	assert.Equal(t, "Sun rises through -0.833°", cross.Fields[2].At(0))
+	assert.Equal(t, "Sun sets through -0.833°", cross.Fields[2].At(1))
+
+	// Only the evening crossings, well before sunset
+	cross = resp.Responses["B"].Frames[0]
+	assert.Equal(t, 2, cross.Rows())
+	for i := 0; i < 2; i++ {
+		crossing := cross.Fields[0].At(i).(time.Time)
+		assert.Equal(t, "Sun sets through 10°", cross.Fields[2].At(i))
+		assert.True(t, crossing.Before(sunset.Fields[0].At(i).(time.Time).Add(-time.Hour)))
+		assert.True(t, crossing.After(sunset.Fields[0].At(i).(time.Time).Add(-2*time.Hour)))
+	}
+
+	assert.ErrorContains(t, resp.Responses["C"].Error, "no sun altitude set for sunAltitudeCross")
+	assert.ErrorContains(t, resp.Responses["D"].Error, "unknown sun direction: sideways")
+
+	// The sun stays below 70 degrees in Vienna
+	assert.Equal(t, 0, resp.Responses["E"].Frames[0].Rows())
+}
@@ -0,0 +1,212 @@
+package plugin
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/sixdouglas/suncalc"
+)
+
+// Sun altitude in degrees at which the blue hour turns into the golden hour
+const blueHourAngle = -4.0
+
+// event is a single occurrence of an annotation found by an event finder
+type event struct {
+	Time time.Time
+	End  time.Time // End of regions
+	Text string    // Replaces the text of the annotation definition if set
+	// Values of the extra fields of the range event, in order
+	Values []interface{}
+}
+
+// eventFinder returns all occurrences at or after from and before to, in order
+type eventFinder func(from time.Time, to time.Time, obs observer) []event
+
+// sortEvents orders events by time
+func sortEvents(events []event) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+}
+
+// rangeEvent finds an annotation that doesn't happen every day over a whole
+// time range instead of day by day
+type rangeEvent struct {
+	find eventFinder
+	// lookback is how far back lastEventTime searches for the latest occurrence
+	lookback time.Duration
+	// step, if set, makes lastEventTime search backwards in windows of this
+	// length and stop at the latest window with an occurrence
+	step time.Duration
+	// fields returns empty extra fields appended to the frame after the tag
+	fields func() []*data.Field
+}
+
+// Lookbacks covering the longest gap between two occurrences
+const (
+	yearLookback  = 366 * 24 * time.Hour
+	monthLookback = 31 * 24 * time.Hour
+)
+
+// rangeEvents are the annotations computed by an event finder
+var rangeEvents = map[string]rangeEvent{
+	"perigee":   {find: perigeeEvents, lookback: monthLookback},
+	"apogee":    {find: apogeeEvents, lookback: monthLookback},
+	"supermoon": {find: supermoonEvents(false), lookback: yearLookback},
+	"micromoon": {find: supermoonEvents(true), lookback: yearLookback},
+
+	"newMoon":      {find: moonPhaseEvents(astro.NewMoon), lookback: monthLookback},
+	"firstQuarter": {find: moonPhaseEvents(astro.FirstQuarter), lookback: monthLookback},
+	"fullMoon":     {find: moonPhaseEvents(astro.FullMoon), lookback: monthLookback},
+	"lastQuarter":  {find: moonPhaseEvents(astro.LastQuarter), lookback: monthLookback},
+
+	"marchEquinox":     {find: solarLongitudeEvents(astro.MarchEquinox, astro.MarchEquinox), lookback: yearLookback},
+	"juneSolstice":     {find: solarLongitudeEvents(astro.JuneSolstice, astro.JuneSolstice), lookback: yearLookback},
+	"septemberEquinox": {find: solarLongitudeEvents(astro.SeptemberEquinox, astro.SeptemberEquinox), lookback: yearLookback},
+	"decemberSolstice": {find: solarLongitudeEvents(astro.DecemberSolstice, astro.DecemberSolstice), lookback: yearLookback},
+
+	// Seasons of the hemisphere of the observer
+	"astronomicalSpring": {find: solarLongitudeEvents(astro.MarchEquinox, astro.SeptemberEquinox), lookback: yearLookback},
+	"astronomicalSummer": {find: solarLongitudeEvents(astro.JuneSolstice, astro.DecemberSolstice), lookback: yearLookback},
+	"astronomicalAutumn": {find: solarLongitudeEvents(astro.SeptemberEquinox, astro.MarchEquinox), lookback: yearLookback},
+	"astronomicalWinter": {find: solarLongitudeEvents(astro.DecemberSolstice, astro.JuneSolstice), lookback: yearLookback},
+
+	"meteorologicalSpring": {find: monthEvents(time.March, time.September), lookback: yearLookback},
+	"meteorologicalSummer": {find: monthEvents(time.June, time.December), lookback: yearLookback},
+	"meteorologicalAutumn": {find: monthEvents(time.September, time.March), lookback: yearLookback},
+	"meteorologicalWinter": {find: monthEvents(time.December, time.June), lookback: yearLookback},
+
+	"sunAltitudeCross": {find: altitudeCrossEvents, lookback: monthLookback},
+
+	"polarDay":   {find: polarEvents(horizonAlwaysUp), lookback: yearLookback},
+	"polarNight": {find: polarEvents(horizonAlwaysDown), lookback: yearLookback},
+
+	"solarEclipse": {find: solarEclipseEvents, lookback: eclipseLookback, step: eclipseSeason, fields: solarEclipseFields},
+	"lunarEclipse": {find: lunarEclipseEvents, lookback: eclipseLookback, step: eclipseSeason, fields: lunarEclipseFields},
+}
+
+// findRangeEvent looks an annotation up in rangeEvents and among the planets,
+// whose events come from the scans of the query
+func findRangeEvent(annotation string, scans planetScans) (rangeEvent, bool) {
+	if target, ok := models.PlanetAnnotations[annotation]; ok {
+		return scans.rangeEvent(target), true
+	}
+	finder, ok := rangeEvents[annotation]
+	return finder, ok
+}
+
+// eventTime returns the time of a point annotation on the local day starting
+// at day, or the zero time if the event doesn't happen on that day. Sunrise,
+// solar noon and sunset come from the engine of the observer, the twilight
+// times from suncalc.
+func eventTime(annotation string, day time.Time, obs observer) time.Time {
+	// Use local noon so suncalc picks the solar transit of this day
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, day.Location())
+	solarTimes := sunTimes(noon, obs)
+	sunrise, solarNoon, sunset := sunRiseNoonSet(noon, obs)
+
+	switch annotation {
+	case "sunrise":
+		return sunrise
+	case "sunriseEnd":
+		return solarTimes[suncalc.SunriseEnd].Value
+	case "goldenHour":
+		return solarTimes[suncalc.GoldenHour].Value
+	case "goldenHourEnd":
+		return solarTimes[suncalc.GoldenHourEnd].Value
+	case "blueHour":
+		return altitudeCrossing(sunset, solarTimes[suncalc.Dusk].Value, obs, blueHourAngle-obs.dip())
+	case "blueHourEnd":
+		return altitudeCrossing(solarTimes[suncalc.Dawn].Value, sunrise, obs, blueHourAngle-obs.dip())
+	case "solarNoon":
+		return solarNoon
+	case "sunsetStart":
+		return solarTimes[suncalc.SunsetStart].Value
+	case "sunset":
+		return sunset
+	case "dusk":
+		return solarTimes[suncalc.Dusk].Value
+	case "nauticalDusk":
+		return solarTimes[suncalc.NauticalDusk].Value
+	case "nauticalDawn":
+		return solarTimes[suncalc.NauticalDawn].Value
+	case "night":
+		return solarTimes[suncalc.Night].Value
+	case "nightEnd":
+		return solarTimes[suncalc.NightEnd].Value
+	case "nadir":
+		return solarTimes[suncalc.Nadir].Value
+	case "dawn":
+		return solarTimes[suncalc.Dawn].Value
+	case "moonrise":
+		return suncalc.GetMoonTimesWithObserver(day, obs.suncalc(day.Location())).Rise
+	case "moonset":
+		return suncalc.GetMoonTimesWithObserver(day, obs.suncalc(day.Location())).Set
+	case "noon":
+		// 12:00:00 PM in the resolved timezone
+		return noon
+	case "midnight":
+		// 12:00:00 AM in the resolved timezone
+		return day
+	}
+
+	return time.Time{}
+}
+
+// regionTimes returns start and end of a region annotation that starts on
+// the local day starting at day. Regions ending after midnight, like the
+// night, take their end event from the following day. Both times are zero
+// if either event doesn't happen.
+func regionTimes(def models.AnnotationDefinition, day time.Time, obs observer) (time.Time, time.Time) {
+	start := eventTime(def.Start, day, obs)
+	if start.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+
+	end := eventTime(def.End, day, obs)
+	if !end.IsZero() && !end.After(start) {
+		end = eventTime(def.End, day.AddDate(0, 0, 1), obs)
+	}
+	if end.IsZero() {
+		return time.Time{}, time.Time{}
+	}
+
+	return start, end
+}
+
+// altitudeCrossing finds the time between from and to at which the sun
+// passes the given altitude in degrees. Returns the zero time if the sun
+// doesn't cross the altitude within the interval.
+func altitudeCrossing(from time.Time, to time.Time, obs observer, angle float64) time.Time {
+	if from.IsZero() || to.IsZero() {
+		return time.Time{}
+	}
+
+	altitude := func(t time.Time) float64 {
+		altitude, _ := sunPosition(t, obs)
+		return altitude - angle
+	}
+
+	low, high := from, to
+	lowAltitude := altitude(low)
+	if math.Signbit(lowAltitude) == math.Signbit(altitude(high)) {
+		return time.Time{}
+	}
+
+	// Bisect down to one second, the altitude is monotonic between two events
+	for high.Sub(low) > time.Second {
+		mid := low.Add(high.Sub(low) / 2)
+		midAltitude := altitude(mid)
+		if math.Signbit(midAltitude) == math.Signbit(lowAltitude) {
+			low, lowAltitude = mid, midAltitude
+		} else {
+			high = mid
+		}
+	}
+
+	return low
+}
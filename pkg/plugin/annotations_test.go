@@ -0,0 +1,94 @@
+package plugin_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/sixdouglas/suncalc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataRegions(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"timezone": "Europe/Berlin", "target": ["eveningCivilTwilight", "eveningBlueHour", "fullNight", "sunset"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 10, 14, 22, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 10, 17, 22, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 4)
+
+	civil, blue, night, sunset := frames[0], frames[1], frames[2], frames[3]
+	assert.Equal(t, "Evening civil twilight", civil.Name)
+	assert.Equal(t, "TimeEnd", civil.Fields[1].Name)
+	assert.Equal(t, 3, civil.Rows())
+	assert.Equal(t, 3, blue.Rows())
+	assert.Equal(t, 3, night.Rows())
+	// Point annotations keep their single time field
+	assert.Equal(t, "Title", sunset.Fields[1].Name)
+
+	for i := 0; i < civil.Rows(); i++ {
+		civilStart := civil.Fields[0].At(i).(time.Time)
+		civilEnd := civil.Fields[1].At(i).(time.Time)
+		blueStart := blue.Fields[0].At(i).(time.Time)
+		blueEnd := blue.Fields[1].At(i).(time.Time)
+
+		// Civil twilight starts at sunset
+		assert.Equal(t, sunset.Fields[0].At(i), civilStart)
+
+		// The blue hour is the darker part of civil twilight
+		assert.True(t, blueStart.After(civilStart))
+		assert.Equal(t, civilEnd, blueEnd)
+		altitude := suncalc.GetPosition(blueStart, ds.Latitude, ds.Longitude).Altitude * (180 / math.Pi)
+		assert.InDelta(t, -4, altitude, 0.01)
+
+		// The night ends on the following morning
+		nightStart := night.Fields[0].At(i).(time.Time)
+		nightEnd := night.Fields[1].At(i).(time.Time)
+		assert.Equal(t, nightStart.YearDay()+1, nightEnd.YearDay())
+		assert.True(t, nightEnd.Sub(nightStart) > 8*time.Hour)
+	}
+}
+
+func TestQueryDataRegionsWithoutNight(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	// The sun stays above -18 degrees in Berlin around the June solstice
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"target": ["fullNight"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Responses["A"].Frames[0].Rows())
+}
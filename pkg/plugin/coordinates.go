@@ -0,0 +1,276 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Axes of a coordinate, name and the hemisphere letters for positive and
+// negative values
+type axis struct {
+	name     string
+	positive byte
+	negative byte
+	limit    float64
+}
+
+var (
+	latitudeAxis  = axis{name: "latitude", positive: 'N', negative: 'S', limit: 90}
+	longitudeAxis = axis{name: "longitude", positive: 'E', negative: 'W', limit: 180}
+)
+
+// coordinateError is an invalid latitude or longitude, with the reason
+type coordinateError struct {
+	axis   string
+	value  string
+	reason string
+}
+
+func (e coordinateError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.axis, e.value, e.reason)
+}
+
+// Maidenhead locator: field, square, subsquare, extended square and extended subsquare
+var maidenheadPattern = regexp.MustCompile(`^[A-Ra-r]{2}(?:[0-9]{2}(?:[A-Xa-x]{2}(?:[0-9]{2}(?:[A-Xa-x]{2})?)?)?)?$`)
+
+// Alphabet of geohashes, without a, i, l and o
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// parseCoordinates parses the latitude and longitude of a query. Each one can
+// be decimal degrees or degrees, minutes and seconds like 52°31'12"N. With an
+// empty longitude the latitude can hold the whole position instead, as a
+// Maidenhead locator like JO62qm, a geohash like u33dc0 or a pair like
+// "52°31'N 13°24'E" or "52.52, 13.40". A value that reads as latitude stays
+// one, and a locator that is also a valid geohash is read as locator. Empty
+// values are returned as nil.
+func parseCoordinates(latitude string, longitude string) (*float64, *float64, error) {
+	latitude, longitude = strings.TrimSpace(latitude), strings.TrimSpace(longitude)
+
+	if latitude != "" && longitude == "" {
+		if _, err := strconv.ParseFloat(latitude, 64); err != nil {
+			if _, err := parseAngle(latitude, latitudeAxis); err != nil {
+				// Not a single latitude, the whole position
+				lat, lon, err := parsePosition(latitude)
+				if err != nil {
+					return nil, nil, err
+				}
+				return &lat, &lon, nil
+			}
+		}
+	}
+
+	var lat, lon *float64
+	if latitude != "" {
+		value, err := parseAngle(latitude, latitudeAxis)
+		if err != nil {
+			return nil, nil, err
+		}
+		lat = &value
+	}
+	if longitude != "" {
+		value, err := parseAngle(longitude, longitudeAxis)
+		if err != nil {
+			return nil, nil, err
+		}
+		lon = &value
+	}
+	return lat, lon, nil
+}
+
+// parsePosition parses a Maidenhead locator, a geohash or a pair of angles
+func parsePosition(s string) (float64, float64, error) {
+	if maidenheadPattern.MatchString(s) {
+		lat, lon := maidenheadCenter(s)
+		return lat, lon, nil
+	}
+	if isGeohash(s) {
+		lat, lon := geohashCenter(s)
+		return lat, lon, nil
+	}
+
+	latitude, longitude, ok := splitPair(s)
+	if !ok {
+		return 0, 0, coordinateError{"latitude", s, "expected degrees, latitude and longitude, a Maidenhead locator or a geohash"}
+	}
+	lat, err := parseAngle(latitude, latitudeAxis)
+	if err != nil {
+		return 0, 0, err
+	}
+	lon, err := parseAngle(longitude, longitudeAxis)
+	if err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+// splitPair splits a position into latitude and longitude, at a comma or a
+// semicolon or else at the hemisphere letter of the latitude
+func splitPair(s string) (string, string, bool) {
+	if i := strings.IndexAny(s, ",;"); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+
+	i := strings.IndexAny(s, "NSns")
+	switch {
+	case i < 0:
+		return "", "", false
+	case i == 0:
+		// Hemisphere before the angles, N52°31' E13°24'
+		j := strings.IndexAny(s, "EWew")
+		if j < 0 {
+			return "", "", false
+		}
+		return s[:j], s[j:], true
+	default:
+		// Hemisphere after the angles, 52°31'N 13°24'E
+		return s[:i+1], s[i+1:], true
+	}
+}
+
+// Unit symbols between degrees, minutes and seconds
+var angleUnits = strings.NewReplacer(
+	"°", " ", "º", " ",
+	"′", " ", "'", " ", "’", " ",
+	"″", " ", "\"", " ", "”", " ",
+)
+
+// parseAngle parses decimal degrees or degrees, minutes and seconds with a
+// sign or a hemisphere letter before or after them, and checks the range of
+// the axis
+func parseAngle(s string, a axis) (float64, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, coordinateError{a.name, original, "empty"}
+	}
+
+	sign := 1.0
+	hemisphere := byte(0)
+	if first := upperLetter(s[0]); first != 0 {
+		hemisphere, s = first, s[1:]
+	} else if last := upperLetter(s[len(s)-1]); last != 0 {
+		hemisphere, s = last, s[:len(s)-1]
+	}
+	s = strings.TrimSpace(s)
+
+	switch hemisphere {
+	case 0:
+		if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+			if s[0] == '-' {
+				sign = -1
+			}
+			s = s[1:]
+		}
+	case a.positive, a.negative:
+		if hemisphere == a.negative {
+			sign = -1
+		}
+		if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+			return 0, coordinateError{a.name, original, "sign and hemisphere together"}
+		}
+	default:
+		return 0, coordinateError{a.name, original, fmt.Sprintf("hemisphere must be %c or %c", a.positive, a.negative)}
+	}
+
+	parts := strings.Fields(angleUnits.Replace(s))
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, coordinateError{a.name, original, "expected degrees, minutes and seconds"}
+	}
+
+	value := 0.0
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil || number < 0 || math.IsInf(number, 0) || math.IsNaN(number) {
+			return 0, coordinateError{a.name, original, fmt.Sprintf("%q is not a number", part)}
+		}
+		if i > 0 && number >= 60 {
+			return 0, coordinateError{a.name, original, "minutes and seconds must be below 60"}
+		}
+		value += number / math.Pow(60, float64(i))
+	}
+
+	value *= sign
+	if value < -a.limit || value > a.limit {
+		return 0, coordinateError{a.name, original, fmt.Sprintf("not in range -%g to +%g", a.limit, a.limit)}
+	}
+	return value, nil
+}
+
+// upperLetter returns an ASCII letter in upper case, or 0 for other bytes
+func upperLetter(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	if c >= 'A' && c <= 'Z' {
+		return c
+	}
+	return 0
+}
+
+// maidenheadCenter returns the center of a Maidenhead locator square
+func maidenheadCenter(locator string) (float64, float64) {
+	locator = strings.ToUpper(locator)
+
+	// Longitude and latitude size of the fields, squares, subsquares and so on
+	lonSizes := []float64{20, 2, 2.0 / 24, 2.0 / 240, 2.0 / 5760}
+	latSizes := []float64{10, 1, 1.0 / 24, 1.0 / 240, 1.0 / 5760}
+
+	lon, lat := -180.0, -90.0
+	pairs := len(locator) / 2
+	for i := 0; i < pairs; i++ {
+		base := byte('A')
+		if i%2 == 1 {
+			base = '0'
+		}
+		lon += float64(locator[2*i]-base) * lonSizes[i]
+		lat += float64(locator[2*i+1]-base) * latSizes[i]
+	}
+	return lat + latSizes[pairs-1]/2, lon + lonSizes[pairs-1]/2
+}
+
+// isGeohash reports whether s only holds geohash characters
+func isGeohash(s string) bool {
+	if len(s) == 0 || len(s) > 12 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if !strings.ContainsRune(geohashAlphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// geohashCenter returns the center of the cell of a geohash
+func geohashCenter(hash string) (float64, float64) {
+	latMin, latMax := -90.0, 90.0
+	lonMin, lonMax := -180.0, 180.0
+
+	// The bits alternate between longitude and latitude, starting with longitude
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		bits := strings.IndexRune(geohashAlphabet, c)
+		for mask := 16; mask > 0; mask >>= 1 {
+			if even {
+				mid := (lonMin + lonMax) / 2
+				if bits&mask != 0 {
+					lonMin = mid
+				} else {
+					lonMax = mid
+				}
+			} else {
+				mid := (latMin + latMax) / 2
+				if bits&mask != 0 {
+					latMin = mid
+				} else {
+					latMax = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return (latMin + latMax) / 2, (lonMin + lonMax) / 2
+}
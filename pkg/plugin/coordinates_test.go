@@ -0,0 +1,97 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLatLonFormats(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 51.1657, Longitude: 10.4515}
+
+	for _, tc := range []struct {
+		latitude  string
+		longitude string
+		lat       float64
+		lon       float64
+	}{
+		{"52°31'N", "13°24'E", 52.516667, 13.4},
+		{"52° 31′ 12″ S", "W 13 24", -52.52, -13.4},
+		{"n52º31'", "-13.4", 52.516667, -13.4},
+		{"52.52", "", 52.52, 10.4515},
+		{"52.52, 13.405", "", 52.52, 13.405},
+		{"52°31'N 13°24'E", "", 52.516667, 13.4},
+		{"S33°52' E151°12'", "", -33.866667, 151.2},
+		// Center of the Maidenhead squares
+		{"JO62qm", "", 52.520833, 13.375},
+		{"jo62", "", 52.5, 13},
+		{"JO", "", 55, 10},
+		{"u33dc0", "", 52.52, 13.40},
+	} {
+		query := backend.DataQuery{JSON: queryJSON(t, map[string]string{"latitude": tc.latitude, "longitude": tc.longitude})}
+		lat, lon, err := ds.GetLatLon(query)
+		if assert.NoError(t, err, tc.latitude) {
+			assert.InDelta(t, tc.lat, lat, 0.01, tc.latitude)
+			assert.InDelta(t, tc.lon, lon, 0.01, tc.latitude)
+		}
+	}
+
+	for _, tc := range []struct {
+		latitude  string
+		longitude string
+		message   string
+	}{
+		{"52°61'N", "13", `invalid latitude "52°61'N": minutes and seconds must be below 60`},
+		{"95", "", `invalid latitude "95": not in range -90 to +90`},
+		{"52", "190", `invalid longitude "190": not in range -180 to +180`},
+		{"13E", "52", `invalid latitude "13E": hemisphere must be N or S`},
+		{"-52°31'N", "13", `invalid latitude "-52°31'N": sign and hemisphere together`},
+		{"52°31'N", "13°24'N", `invalid longitude "13°24'N": hemisphere must be E or W`},
+		{"north", "", `invalid latitude "north": expected degrees, latitude and longitude, a Maidenhead locator or a geohash`},
+	} {
+		query := backend.DataQuery{JSON: queryJSON(t, map[string]string{"latitude": tc.latitude, "longitude": tc.longitude})}
+		_, _, err := ds.GetLatLon(query)
+		assert.EqualError(t, err, tc.message, tc.latitude)
+	}
+}
+
+func TestQueryDataSiteCoordinates(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 45.0, Longitude: 9.0}
+
+	day := backend.TimeRange{
+		From: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"locations": [{"name": "Berlin", "latitude": "JO62qm"}, {"name": "Potsdam", "latitude": "52°24'N", "longitude": "13°04'E"}], "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "B", JSON: []byte(`{"locations": [{"name": "Berlin", "latitude": "52°31'N"}], "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "C", JSON: []byte(`{"locations": [{"name": "Berlin", "latitude": "52°31'N", "longitude": "13°99'E"}], "target": ["sunrise"]}`), TimeRange: day},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Sunrise in Potsdam, a little west, comes a minute later
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 2)
+	berlin := frames[0].Fields[0].At(0).(time.Time)
+	potsdam := frames[1].Fields[0].At(0).(time.Time)
+	assert.InDelta(t, time.Minute.Seconds(), potsdam.Sub(berlin).Seconds(), 60)
+
+	assert.ErrorContains(t, resp.Responses["B"].Error, "invalid longitude of Berlin: empty")
+	assert.ErrorContains(t, resp.Responses["C"].Error, `invalid longitude of Berlin "13°99'E": minutes and seconds must be below 60`)
+}
+
+func queryJSON(t *testing.T, fields map[string]string) []byte {
+	t.Helper()
+	b, err := json.Marshal(fields)
+	assert.NoError(t, err)
+	return b
+}
@@ -4,27 +4,38 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
-	"github.com/sixdouglas/suncalc"
 )
 
 var (
 	_ backend.QueryDataHandler      = (*Datasource)(nil)
 	_ backend.CheckHealthHandler    = (*Datasource)(nil)
+	_ backend.CallResourceHandler   = (*Datasource)(nil)
+	_ backend.StreamHandler         = (*Datasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*Datasource)(nil)
 )
 
 func NewDatasource(_ context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 	var jsonData struct {
-		Latitude  float64 `json:"latitude"`
-		Longitude float64 `json:"longitude"`
+		Latitude    float64  `json:"latitude"`
+		Longitude   float64  `json:"longitude"`
+		Timezone    string   `json:"timezone"`
+		Elevation   float64  `json:"elevation"`
+		Pressure    *float64 `json:"pressure"`
+		Temperature *float64 `json:"temperature"`
+
+		LinkeTurbidity *float64 `json:"linkeTurbidity"`
+
+		PVArrays []models.PVArray `json:"pvArrays"`
+
+		Locations []models.Location `json:"locations"`
 	}
 
 	// Parse settings to get the default latitude and longitude
@@ -33,9 +44,25 @@ func NewDatasource(_ context.Context, settings backend.DataSourceInstanceSetting
 		return nil, err
 	}
 
+	// Queries refer to the locations by name, a broken registry would fail them all
+	if err := models.ValidateLocations(jsonData.Locations); err != nil {
+		return nil, err
+	}
+
 	return &Datasource{
 		Latitude:  jsonData.Latitude,  // Set the default latitude
 		Longitude: jsonData.Longitude, // Set the default longitude
+		Timezone:  jsonData.Timezone,  // Set the default timezone
+
+		Elevation:   jsonData.Elevation,   // Set the default observer elevation
+		Pressure:    jsonData.Pressure,    // Set the default air pressure
+		Temperature: jsonData.Temperature, // Set the default air temperature
+
+		LinkeTurbidity: jsonData.LinkeTurbidity, // Set the default turbidity of the clear sky
+
+		PVArrays: jsonData.PVArrays, // Set the PV arrays for the expected power
+
+		Locations: jsonData.Locations, // Set the named locations queries refer to
 	}, nil
 }
 
@@ -47,193 +74,292 @@ func (d *Datasource) Dispose() {
 type Datasource struct {
 	Latitude  float64
 	Longitude float64
+	Timezone  string // IANA timezone name, empty means not configured
+
+	Elevation   float64  // Observer height above sea level in meters
+	Pressure    *float64 // Air pressure in mbar, standard atmosphere if not set
+	Temperature *float64 // Air temperature in °C, standard atmosphere if not set
+
+	LinkeTurbidity *float64 // Linke turbidity for the clear-sky irradiance, defaultLinkeTurbidity if not set
+
+	PVArrays []models.PVArray // PV arrays for the expected power
+
+	Locations []models.Location // Named locations queries refer to
 }
 
 type queryModel struct {
-	Latitude  string   `json:"latitude"`
-	Longitude string   `json:"longitude"`
-	Target    []string `json:"target"`
+	Latitude          string   `json:"latitude"`
+	Longitude         string   `json:"longitude"`
+	Timezone          string   `json:"timezone"`
+	Elevation         string   `json:"elevation"`   // Meters above sea level
+	Pressure          string   `json:"pressure"`    // Air pressure in mbar
+	Temperature       string   `json:"temperature"` // Air temperature in °C
+	DashboardTimezone string   `json:"dashboardTimezone"`
+	Target            []string `json:"target"`
+	Live              bool     `json:"live"`
+	LiveInterval      int      `json:"liveInterval"` // Push interval in seconds
+	Engine            string   `json:"engine"`       // Solar position engine, "suncalc" or "spa"
+	DeltaT            *float64 `json:"deltaT"`       // TT - UT in seconds for the SPA engine
+
+	SupermoonThreshold *float64 `json:"supermoonThreshold"` // Percent of the way from apogee to perigee
+
+	LinkeTurbidity string `json:"linkeTurbidity"` // Turbidity of the clear sky at airmass 2
+	ClearSkyModel  string `json:"clearSkyModel"`  // Clear-sky irradiance model, "ineichen" or "haurwitz"
+
+	SurfaceTilt         string   `json:"surfaceTilt"`         // Degrees from horizontal
+	SurfaceAzimuth      string   `json:"surfaceAzimuth"`      // Degrees clockwise from north
+	Tracker             string   `json:"tracker"`             // "fixed" or "singleAxis"
+	MaxAngle            *float64 `json:"maxAngle"`            // Rotation limit of the tracker in degrees
+	GroundCoverageRatio *float64 `json:"groundCoverageRatio"` // Module width over row spacing of the tracker
+
+	PVArray string `json:"pvArray"` // Name of a single PV array, all arrays if empty
+
+	SunAltitude  string `json:"sunAltitude"`  // Degrees the sunAltitudeCross annotation looks for
+	SunDirection string `json:"sunDirection"` // "rising" or "setting", both if empty
+
+	Location  string          `json:"location"`  // Name of a location of the datasource replacing latitude and longitude
+	Locations []queryLocation `json:"locations"` // Named sites replacing the single location
 }
 
-// QueryData handles multiple queries
+// QueryData handles multiple queries. Problems with a single query are
+// reported on its own response, so the other queries still return data.
 func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	response := backend.NewQueryDataResponse()
+	alerting := fromAlert(req)
 
 	// Iterate over each query
 	for _, query := range req.Queries {
+		response.Responses[query.RefID] = d.query(ctx, req.PluginContext, query, alerting)
+	}
 
-		// Assuming the interval is provided in milliseconds, extract it from the request
-		intervalMs := query.Interval.Milliseconds() // Get the interval from the request (assuming it's a duration)
+	return response, nil
+}
 
-		// Ensure a default interval in case none is provided
-		if intervalMs == 0 {
-			intervalMs = 1000 * 60 * 30 // Default to 30 minutes if no interval is provided
+// query computes the frames of a single query
+func (d *Datasource) query(_ context.Context, pCtx backend.PluginContext, query backend.DataQuery, alerting bool) (response backend.DataResponse) {
+	// A failing computation is a bug in the plugin, don't take the other queries down with it
+	defer func() {
+		if r := recover(); r != nil {
+			response = backend.ErrDataResponseWithSource(backend.StatusInternal, backend.ErrorSourcePlugin, fmt.Sprintf("query %s failed: %v", query.RefID, r))
 		}
+	}()
 
-		// Parse the query JSON to get metrics and annotations
-		metrics, annotations := getMetricsAndAnnotations(query)
-		latitude, longitude, _ := d.GetLatLon(query)
+	var qm queryModel
+	if err := json.Unmarshal(query.JSON, &qm); err != nil {
+		return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, fmt.Sprintf("error unmarshalling query JSON: %v", err))
+	}
 
-		// Process each metric and add data points to frames
-		if len(metrics) > 0 {
-			for _, metric := range metrics {
+	// Parse the targets into metrics and annotations
+	metrics, annotations, unknown := getMetricsAndAnnotations(qm.Target)
+	if len(unknown) > 0 {
+		return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, fmt.Sprintf("unknown target: %s", strings.Join(unknown, ", ")))
+	}
 
-				// Retrieve the metric configuration
-				metricDef, ok := models.SunAndMoonMetrics[metric]
-				if !ok {
-					return nil, fmt.Errorf("unknown metric: %s", metric) // Return nil for the response and the error
-				}
-				// Convert Min value to *data.ConfFloat64
-				minValue := data.ConfFloat64(metricDef.Config.Min)
-
-				// Create a new Frame and set the RefID and name (similar to the TypeScript example)
-				frame := data.NewFrame(metricDef.Title) // Set the frame name using the metric's title
-
-				// Add fields for Time and Value to the Frame
-				frame.Fields = append(frame.Fields,
-					data.NewField("Time", nil, []time.Time{}), // Time field, equivalent to FieldType.time in TS
-					data.NewField("Value", nil, []float64{}).SetConfig(&data.FieldConfig{
-						Unit:     metricDef.Config.Unit,                        // Use the unit from the metric configuration
-						Decimals: uint16Ptr(uint16(metricDef.Config.Decimals)), // Set decimal places as *uint16
-						Min:      &minValue,                                    // Set minimum value as a pointer to data.ConfFloat64
-					}),
-				)
-
-				// Iterate over the time range using the interval from the request
-				for t := query.TimeRange.From; t.Before(query.TimeRange.To); t = t.Add(time.Duration(intervalMs) * time.Millisecond) {
-					var value float64
-
-					// Use dummy values for now
-					switch metric {
-					case "moon_illumination":
-						value = suncalc.GetMoonIllumination(t).Fraction
-
-					case "moon_altitude":
-						// Get the moon's altitude (in radians) and convert it to degrees
-						value = suncalc.GetMoonPosition(t, latitude, longitude).Altitude * (180 / math.Pi)
-
-					case "moon_azimuth":
-						// Get the moon's azimuth (in radians) and convert it to degrees, adding 180 degrees
-						value = suncalc.GetMoonPosition(t, latitude, longitude).Azimuth*(180/math.Pi) + 180
-
-					case "moon_distance":
-						// Get the distance to the moon in kilometers
-						value = suncalc.GetMoonPosition(t, latitude, longitude).Distance
-
-					case "sun_altitude":
-						// Get the sun's altitude (in radians) and convert it to degrees
-						value = suncalc.GetPosition(t, latitude, longitude).Altitude * (180 / math.Pi) // Convert radians to degrees
-
-					case "sun_azimuth":
-						// Get the sun's azimuth (in radians) and convert it to degrees, adding 180 degrees
-						value = suncalc.GetPosition(t, latitude, longitude).Azimuth*(180/math.Pi) + 180 // Convert radians to degrees and add 180
-
-					case "sun_maximum_altitude":
-						// Get the solar noon time, then calculate the sun's altitude at solar noon
-						solarNoon := suncalc.GetTimes(t, latitude, longitude)[suncalc.SolarNoon].Value
-						value = suncalc.GetPosition(solarNoon, latitude, longitude).Altitude * (180 / math.Pi) // Convert radians to degrees
-					}
-
-					// Append dummy values to the frame
-					frame.AppendRow(t, value)
-				}
+	sites, err := d.querySites(query, qm)
+	if err != nil {
+		return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+	}
+	if qm.Live && !alerting && len(sites) > 1 {
+		return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, "live queries take a single location")
+	}
 
-				// Check if a response exists for this RefID
-				dataResponse, exists := response.Responses[query.RefID]
-				if !exists {
-					dataResponse = backend.DataResponse{
-						Frames: []*data.Frame{},
-					}
-				}
-				// Append the frame to the existing frames for the query
-				dataResponse.Frames = append(dataResponse.Frames, frame)
+	response.Frames = []*data.Frame{}
+	for _, site := range sites {
+		obs, err := d.queryObserver(qm, site.Latitude, site.Longitude)
+		if err != nil {
+			return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+		}
+		if err := obs.validate(); err != nil {
+			return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+		}
+		if err := checkPVArrays(metrics, obs); err != nil {
+			return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+		}
+		if err := checkAltitudeCross(annotations, obs); err != nil {
+			return backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, err.Error())
+		}
+		// The elevation of the query wins over the one of the location
+		if site.Elevation != nil && qm.Elevation == "" {
+			obs.Elevation = *site.Elevation
+		}
 
-				// Reassign the dataResponse back to the map
-				response.Responses[query.RefID] = dataResponse
+		// Live queries return the current position and subscribe to its updates
+		if qm.Live && !alerting {
+			frame := positionFrame(time.Now(), obs)
+			if settings := pCtx.DataSourceInstanceSettings; settings != nil {
+				frame.SetMeta(&data.FrameMeta{Channel: d.streamChannel(settings.UID, obs, qm.LiveInterval)})
 			}
+			return backend.DataResponse{Frames: []*data.Frame{frame}}
 		}
 
-		// Process each annotation and add data to frames
-		if len(annotations) > 0 {
-			for _, annotation := range annotations {
-				frame := data.NewFrame(models.SunAndMoonAnnotations[annotation].Title,
-					data.NewField("Time", nil, []time.Time{}),
-					data.NewField("Title", nil, []string{}),
-					data.NewField("Text", nil, []string{}),
-					data.NewField("Tag", nil, []string{}),
-				)
-
-				// Iterate over each day in the time range
-				for t := query.TimeRange.From; t.Before(query.TimeRange.To); t = t.AddDate(0, 0, 1) {
-					var eventTime time.Time
-					solarTimes := suncalc.GetTimes(t, latitude, longitude)
-					moonTimes := suncalc.GetMoonTimes(t, latitude, longitude, false)
-
-					switch annotation {
-					case "sunrise":
-						eventTime = solarTimes[suncalc.Sunrise].Value
-					case "sunriseEnd":
-						eventTime = solarTimes[suncalc.SunriseEnd].Value
-					case "goldenHour":
-						eventTime = solarTimes[suncalc.GoldenHour].Value
-					case "goldenHourEnd":
-						eventTime = solarTimes[suncalc.GoldenHourEnd].Value
-					case "solarNoon":
-						eventTime = solarTimes[suncalc.SolarNoon].Value
-					case "sunsetStart":
-						eventTime = solarTimes[suncalc.SunsetStart].Value
-					case "sunset":
-						eventTime = solarTimes[suncalc.Sunset].Value
-					case "dusk":
-						eventTime = solarTimes[suncalc.Dusk].Value
-					case "nauticalDusk":
-						eventTime = solarTimes[suncalc.NauticalDusk].Value
-					case "nauticalDawn":
-						eventTime = solarTimes[suncalc.NauticalDawn].Value
-					case "night":
-						eventTime = solarTimes[suncalc.Night].Value
-					case "nightEnd":
-						eventTime = solarTimes[suncalc.NightEnd].Value
-					case "nadir":
-						eventTime = solarTimes[suncalc.Nadir].Value
-					case "dawn":
-						eventTime = solarTimes[suncalc.Dawn].Value
-					case "moonrise":
-						eventTime = moonTimes.Rise
-					case "moonset":
-						eventTime = moonTimes.Set
-					case "noon": //FIXME: Always interpreted as UTC
-						// Set to 12:00:00 PM for noon
-						eventTime = time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, time.Local)
-					case "midnight": //FIXME: Always interpreted as UTC
-						// Set to 12:00:00 AM for midnight
-						eventTime = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
-					}
-
-					// Check if eventTime is valid (not zero)
-					if !eventTime.IsZero() {
-						def := models.SunAndMoonAnnotations[annotation]
-						frame.AppendRow(eventTime, def.Title, def.Text, def.Tag)
-					}
+		response.Frames = append(response.Frames, siteFrames(query, metrics, annotations, site, obs, alerting)...)
+	}
+
+	return response
+}
+
+// siteFrames computes the frames of the metrics and annotations of a query
+// for a single site
+func siteFrames(query backend.DataQuery, metrics []string, annotations []string, site site, obs observer, alerting bool) []*data.Frame {
+	location := site.Location
+
+	// Assuming the interval is provided in milliseconds, extract it from the request
+	intervalMs := query.Interval.Milliseconds() // Get the interval from the request (assuming it's a duration)
+
+	// Ensure a default interval in case none is provided
+	if intervalMs == 0 {
+		intervalMs = 1000 * 60 * 30 // Default to 30 minutes if no interval is provided
+	}
+
+	frames := []*data.Frame{}
+
+	// Process each metric and add data points to frames
+	for _, metric := range metrics {
+		metricDef := models.SunAndMoonMetrics[metric]
+
+		// Convert Min value to *data.ConfFloat64
+		minValue := data.ConfFloat64(metricDef.Config.Min)
+		config := &data.FieldConfig{
+			DisplayNameFromDS: site.displayName(metricDef.Title),            // Keep the title as series name despite the labels
+			Unit:              metricDef.Config.Unit,                        // Use the unit from the metric configuration
+			Decimals:          uint16Ptr(uint16(metricDef.Config.Decimals)), // Set decimal places as *uint16
+			Min:               &minValue,                                    // Set minimum value as a pointer to data.ConfFloat64
+			Mappings:          stateMappings(metricDef.Config.States),       // Names for enum states, if any
+		}
+		labels := site.labels(metric)
+
+		if alerting {
+			// Alert rules evaluate the value at the end of the window, however short it is
+			value := metricValue(metric, query.TimeRange.To.In(location), obs)
+			frames = append(frames, numericFrame(metricDef.Title, &value, labels, config))
+			continue
+		}
+
+		// Create a new Frame and set the RefID and name (similar to the TypeScript example)
+		frame := data.NewFrame(site.displayName(metricDef.Title)) // Set the frame name using the metric's title
+
+		// Add fields for Time and Value to the Frame, text metrics return the name of their state
+		var valueField *data.Field
+		if metricDef.Config.Text {
+			valueField = data.NewField("Value", labels, []string{})
+			config.Mappings = nil // The values are the names already
+		} else {
+			valueField = data.NewField("Value", labels, []float64{})
+		}
+		frame.Fields = append(frame.Fields,
+			data.NewField("Time", nil, []time.Time{}), // Time field, equivalent to FieldType.time in TS
+			valueField.SetConfig(config),
+		)
+
+		// Daily metrics have a value per local day, the others one per interval of the request
+		var times []time.Time
+		if metricDef.Config.Daily {
+			times = localDays(query.TimeRange, location)
+		} else {
+			for t := query.TimeRange.From; t.Before(query.TimeRange.To); t = t.Add(time.Duration(intervalMs) * time.Millisecond) {
+				times = append(times, t)
+			}
+		}
+
+		for _, t := range times {
+			value := metricValue(metric, t, obs)
+
+			if metricDef.Config.Text {
+				frame.AppendRow(t, metricDef.Config.States[int(value)])
+			} else {
+				frame.AppendRow(t, value)
+			}
+		}
+
+		frames = append(frames, frame)
+	}
+
+	// Process each annotation and add data to frames
+	scans := planetScans{}
+	for _, target := range annotations {
+		annotation, offset, _ := parseAnnotationTarget(target)
+		baseDef := models.SunAndMoonAnnotations[annotation]
+		def := offsetDefinition(baseDef, offset)
+
+		// Alert rules can't use events, they get the time passed since the latest one
+		if alerting {
+			var since *float64
+			// The latest shifted event is the latest one before the shifted end of the window
+			if last := lastEventTime(annotation, baseDef, query.TimeRange.To.In(location).Add(-offset), obs, scans); !last.IsZero() {
+				seconds := query.TimeRange.To.Sub(last.Add(offset)).Seconds()
+				since = &seconds
+			}
+			frames = append(frames, numericFrame(def.Title, since, site.labels(target), &data.FieldConfig{
+				DisplayNameFromDS: site.displayName(def.Title),
+				Unit:              "s",
+			}))
+			continue
+		}
+
+		var frame *data.Frame
+		if def.IsRegion() {
+			// Region annotation spanning from one event to another
+			frame = data.NewFrame(site.displayName(def.Title),
+				data.NewField("Time", nil, []time.Time{}),
+				data.NewField("TimeEnd", nil, []time.Time{}),
+				data.NewField("Title", nil, []string{}),
+				data.NewField("Text", nil, []string{}),
+				data.NewField("Tag", nil, []string{}),
+			)
+		} else {
+			frame = data.NewFrame(site.displayName(def.Title),
+				data.NewField("Time", nil, []time.Time{}),
+				data.NewField("Title", nil, []string{}),
+				data.NewField("Text", nil, []string{}),
+				data.NewField("Tag", nil, []string{}),
+			)
+		}
+
+		// Shifted events fall into the range if the events themselves fall into the shifted range
+		shifted := backend.TimeRange{From: query.TimeRange.From.Add(-offset), To: query.TimeRange.To.Add(-offset)}
+
+		// Events that don't happen daily are found over the whole range at once
+		if finder, ok := findRangeEvent(annotation, scans); ok {
+			if finder.fields != nil {
+				frame.Fields = append(frame.Fields, finder.fields()...)
+			}
+			for _, event := range finder.find(shifted.From.In(location), shifted.To.In(location), obs) {
+				text := def.Text
+				if event.Text != "" {
+					text = offsetText(baseDef, event.Text, offset)
+				}
+				row := []interface{}{event.Time.Add(offset)}
+				if def.IsRegion() {
+					row = append(row, event.End.Add(offset))
 				}
+				row = append(row, def.Title, text, def.Tag)
+				frame.AppendRow(append(row, event.Values...)...)
+			}
+			frames = append(frames, site.labelFields(frame))
+			continue
+		}
 
-				// Check if a response exists for this RefID
-				dataResponse, exists := response.Responses[query.RefID]
-				if !exists {
-					dataResponse = backend.DataResponse{
-						Frames: []*data.Frame{},
-					}
+		// Iterate over each local day in the time range
+		for _, day := range localDays(shifted, location) {
+			if def.End != "" {
+				start, end := regionTimes(def, day, obs)
+				if !start.IsZero() {
+					frame.AppendRow(start.Add(offset), end.Add(offset), def.Title, def.Text, def.Tag)
 				}
-				// Append the frame to the existing frames for the query
-				dataResponse.Frames = append(dataResponse.Frames, frame)
+				continue
+			}
 
-				// Reassign the dataResponse back to the map
-				response.Responses[query.RefID] = dataResponse
+			// Check if eventTime is valid (not zero)
+			eventTime := eventTime(annotation, day, obs)
+			if !eventTime.IsZero() {
+				frame.AppendRow(eventTime.Add(offset), def.Title, def.Text, def.Tag)
 			}
 		}
+
+		frames = append(frames, site.labelFields(frame))
 	}
 
-	return response, nil
+	return frames
 }
 
 // Helper function to convert int to *uint16
@@ -251,41 +377,132 @@ func (d *Datasource) GetLatLon(query backend.DataQuery) (float64, float64, error
 	latitude := d.Latitude
 	longitude := d.Longitude
 
-	// Convert latitude and longitude from strings to float64
-	if qm.Latitude != "" {
-		latitude, err = strconv.ParseFloat(qm.Latitude, 64)
+	// Convert latitude and longitude from strings, see parseCoordinates
+	lat, lon, err := parseCoordinates(qm.Latitude, qm.Longitude)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lat != nil {
+		latitude = *lat
+	}
+	if lon != nil {
+		longitude = *lon
+	}
+
+	return latitude, longitude, nil
+}
+
+// observer returns an observer at the given location with the elevation and
+// atmosphere configured for the datasource
+func (d *Datasource) observer(latitude float64, longitude float64) observer {
+	return observer{
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Elevation:   d.Elevation,
+		Pressure:    d.Pressure,
+		Temperature: d.Temperature,
+
+		LinkeTurbidity: d.LinkeTurbidity,
+
+		PVArrays: d.PVArrays,
+	}
+}
+
+// queryObserver returns the observer of a query, its elevation and
+// atmosphere settings override the ones of the datasource
+func (d *Datasource) queryObserver(qm queryModel, latitude float64, longitude float64) (observer, error) {
+	obs := d.observer(latitude, longitude)
+	obs.Engine = qm.Engine
+	obs.DeltaT = qm.DeltaT
+	obs.SupermoonThreshold = qm.SupermoonThreshold
+	obs.ClearSkyModel = qm.ClearSkyModel
+	obs.Surface.Tracker = qm.Tracker
+	obs.Surface.MaxAngle = qm.MaxAngle
+	obs.Surface.GroundCoverageRatio = qm.GroundCoverageRatio
+	obs.AltitudeCross.Direction = qm.SunDirection
+
+	arrays, err := pvArrays(d.PVArrays, qm.PVArray)
+	if err != nil {
+		return obs, err
+	}
+	obs.PVArrays = arrays
+
+	if qm.Elevation != "" {
+		elevation, err := strconv.ParseFloat(qm.Elevation, 64)
 		if err != nil {
-			return 0, 0, fmt.Errorf("invalid latitude: %v", err)
+			return obs, fmt.Errorf("invalid elevation: %v", err)
 		}
+		obs.Elevation = elevation
 	}
 
-	if qm.Longitude != "" {
-		longitude, err = strconv.ParseFloat(qm.Longitude, 64)
+	if qm.Pressure != "" {
+		pressure, err := strconv.ParseFloat(qm.Pressure, 64)
 		if err != nil {
-			return 0, 0, fmt.Errorf("invalid longitude: %v", err)
+			return obs, fmt.Errorf("invalid pressure: %v", err)
 		}
+		obs.Pressure = &pressure
 	}
 
-	return latitude, longitude, nil
+	if qm.Temperature != "" {
+		temperature, err := strconv.ParseFloat(qm.Temperature, 64)
+		if err != nil {
+			return obs, fmt.Errorf("invalid temperature: %v", err)
+		}
+		obs.Temperature = &temperature
+	}
+
+	if qm.LinkeTurbidity != "" {
+		turbidity, err := strconv.ParseFloat(qm.LinkeTurbidity, 64)
+		if err != nil {
+			return obs, fmt.Errorf("invalid linke turbidity: %v", err)
+		}
+		obs.LinkeTurbidity = &turbidity
+	}
+
+	if qm.SurfaceTilt != "" {
+		tilt, err := strconv.ParseFloat(qm.SurfaceTilt, 64)
+		if err != nil {
+			return obs, fmt.Errorf("invalid surface tilt: %v", err)
+		}
+		obs.Surface.Tilt = tilt
+	}
+
+	if qm.SurfaceAzimuth != "" {
+		azimuth, err := strconv.ParseFloat(qm.SurfaceAzimuth, 64)
+		if err != nil {
+			return obs, fmt.Errorf("invalid surface azimuth: %v", err)
+		}
+		obs.Surface.Azimuth = &azimuth
+	}
+
+	if qm.SunAltitude != "" {
+		altitude, err := strconv.ParseFloat(qm.SunAltitude, 64)
+		if err != nil {
+			return obs, fmt.Errorf("invalid sun altitude: %v", err)
+		}
+		obs.AltitudeCross.Altitude = &altitude
+	}
+
+	return obs, nil
 }
 
-// Helper function to get metrics and annotations from the query
-func getMetricsAndAnnotations(query backend.DataQuery) ([]string, []string) {
+// Helper function to split the query targets into metrics and annotations,
+// targets found in neither catalogue are returned as unknown
+func getMetricsAndAnnotations(targets []string) ([]string, []string, []string) {
 	metrics := []string{}
 	annotations := []string{}
+	unknown := []string{}
 
-	// Unmarshal the query and filter targets into metrics and annotations
-	var qm models.SunAndMoonQuery
-	_ = json.Unmarshal(query.JSON, &qm)
-
-	for _, target := range *qm.Target {
+	for _, target := range targets {
 		if _, ok := models.SunAndMoonMetrics[target]; ok {
 			metrics = append(metrics, target)
-		} else if _, ok := models.SunAndMoonAnnotations[target]; ok {
+		} else if _, _, ok := parseAnnotationTarget(target); ok {
 			annotations = append(annotations, target)
+		} else {
+			unknown = append(unknown, target)
 		}
 	}
-	return metrics, annotations
+	return metrics, annotations, unknown
 }
 
 // CheckHealth verifies the datasource settings
@@ -302,6 +519,30 @@ func (d *Datasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequ
 		errors = append(errors, "Longitude not in range -180 to +180.")
 	}
 
+	// Check for a valid timezone
+	if d.Timezone != "" {
+		if _, err := models.LoadLocation(d.Timezone); err != nil {
+			errors = append(errors, fmt.Sprintf("Unknown timezone %q.", d.Timezone))
+		}
+	}
+
+	// Check for a plausible atmosphere
+	if d.Pressure != nil && *d.Pressure <= 0 {
+		errors = append(errors, "Pressure must be above 0 mbar.")
+	}
+	if d.Temperature != nil && *d.Temperature <= -273.15 {
+		errors = append(errors, "Temperature must be above -273.15 °C.")
+	}
+	if d.LinkeTurbidity != nil && (*d.LinkeTurbidity < 1 || *d.LinkeTurbidity > 10) {
+		errors = append(errors, "Linke turbidity not in range 1 to 10.")
+	}
+	if err := models.ValidatePVArrays(d.PVArrays); err != nil {
+		errors = append(errors, err.Error()+".")
+	}
+	if err := models.ValidateLocations(d.Locations); err != nil {
+		errors = append(errors, err.Error()+".")
+	}
+
 	// Return errors if any, else return success
 	if len(errors) > 0 {
 		return &backend.CheckHealthResult{
@@ -127,3 +127,46 @@ func TestGetLatLon(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestQueryDataErrorsPerQuery(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  45.0,
+		Longitude: 8.9718784,
+	}
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 10, 16, 0, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["sun_altitude"]}`), TimeRange: timeRange},
+			{RefID: "B", JSON: []byte(`{"target": ["sun_altitude", "sun_brightness"]}`), TimeRange: timeRange},
+			{RefID: "C", JSON: []byte(`{"latitude": "north", "target": ["sunrise"]}`), TimeRange: timeRange},
+			{RefID: "D", JSON: []byte(`{"timezone": "Mars/Olympus", "target": ["noon"]}`), TimeRange: timeRange},
+			{RefID: "E", JSON: []byte(`{}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Responses, 5)
+
+	// Valid queries still return data
+	assert.NoError(t, resp.Responses["A"].Error)
+	assert.Len(t, resp.Responses["A"].Frames, 1)
+	assert.NoError(t, resp.Responses["E"].Error)
+	assert.Empty(t, resp.Responses["E"].Frames)
+
+	// Invalid queries report the problem as a user error
+	for refID, message := range map[string]string{
+		"B": "unknown target: sun_brightness",
+		"C": "invalid latitude",
+		"D": "invalid timezone",
+	} {
+		assert.ErrorContains(t, resp.Responses[refID].Error, message, refID)
+		assert.Equal(t, backend.ErrorSourceDownstream, resp.Responses[refID].ErrorSource, refID)
+		assert.Equal(t, backend.StatusBadRequest, resp.Responses[refID].Status, refID)
+		assert.Empty(t, resp.Responses[refID].Frames, refID)
+	}
+}
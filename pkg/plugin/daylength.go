@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// Sun altitudes in degrees that suncalc uses for sunrise and the twilights
+const (
+	sunriseAngle              = -0.833
+	civilTwilightAngle        = -6.0
+	nauticalTwilightAngle     = -12.0
+	astronomicalTwilightAngle = -18.0
+)
+
+// Morning and evening suncalc times at which the sun crosses a twilight angle
+var twilightTimes = map[float64][2]suncalc.DayTimeName{
+	civilTwilightAngle:        {suncalc.Dawn, suncalc.Dusk},
+	nauticalTwilightAngle:     {suncalc.NauticalDawn, suncalc.NauticalDusk},
+	astronomicalTwilightAngle: {suncalc.NightEnd, suncalc.Night},
+}
+
+// sunAboveDuration returns how long the sun stays above the given altitude
+// on the local day of t, for sunriseAngle or one of the twilight angles, all
+// from the engine of the observer. With SPA the twilight crossings are found
+// on either side of the SPA transit. Without a crossing the sun stays above
+// or below the angle all day long, decided by its altitude at solar noon.
+func sunAboveDuration(t time.Time, obs observer, angle float64) time.Duration {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+
+	morning, solarNoon, evening := sunRiseNoonSet(noon, obs)
+	if names, ok := twilightTimes[angle]; ok {
+		if obs.Engine == engineSPA {
+			// The altitude rises from the lower transit to the transit and falls after it
+			morning = altitudeCrossing(solarNoon.Add(-12*time.Hour), solarNoon, obs, angle-obs.dip())
+			evening = altitudeCrossing(solarNoon, solarNoon.Add(12*time.Hour), obs, angle-obs.dip())
+		} else {
+			solarTimes := sunTimes(noon, obs)
+			morning, evening = solarTimes[names[0]].Value, solarTimes[names[1]].Value
+		}
+	}
+
+	if !morning.IsZero() && !evening.IsZero() {
+		return evening.Sub(morning)
+	}
+
+	if altitude, _ := sunPosition(solarNoon, obs); altitude+obs.dip() > angle {
+		// The whole local day, which is shorter or longer when the clocks change
+		return day.AddDate(0, 0, 1).Sub(day)
+	}
+	return 0
+}
+
+// dayLength returns the time from sunrise to sunset on the local day of t
+func dayLength(t time.Time, obs observer) time.Duration {
+	return sunAboveDuration(t, obs, sunriseAngle)
+}
+
+// twilightDuration returns how long the sun stays between the upper and the
+// lower angle on the local day of t, morning and evening together
+func twilightDuration(t time.Time, obs observer, upper float64, lower float64) time.Duration {
+	return sunAboveDuration(t, obs, lower) - sunAboveDuration(t, obs, upper)
+}
@@ -0,0 +1,133 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+// dailyValues returns the values of the daily metric frames of a response by metric
+func dailyValues(t *testing.T, frames data.Frames) map[string][]float64 {
+	values := map[string][]float64{}
+	for _, frame := range frames {
+		metric := frame.Fields[1].Labels["target"]
+		for i := 0; i < frame.Rows(); i++ {
+			// One point per local day at midnight
+			assert.Equal(t, 0, frame.Fields[0].At(i).(time.Time).Hour())
+			values[metric] = append(values[metric], frame.Fields[1].At(i).(float64))
+		}
+	}
+	return values
+}
+
+func TestQueryDataDayLength(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Vienna"}
+
+	// Three days after the summer solstice in Vienna
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["day_length", "day_length_delta", "civil_twilight_duration", "nautical_twilight_duration", "astronomical_twilight_duration"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 24, 22, 0, 0, 0, time.UTC),
+			}, Interval: time.Hour},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	values := dailyValues(t, resp.Responses["A"].Frames)
+
+	assert.Len(t, values["day_length"], 3)
+	assert.InDelta(t, (16*time.Hour + 5*time.Minute).Seconds(), values["day_length"][0], 60)
+
+	// The days get shorter by a few seconds after the solstice
+	for _, delta := range values["day_length_delta"] {
+		assert.Less(t, delta, 0.0)
+		assert.Greater(t, delta, -30.0)
+	}
+
+	// Civil twilight lasts about 40 minutes on each side, the night only about an hour
+	assert.InDelta(t, (80 * time.Minute).Seconds(), values["civil_twilight_duration"][0], 600)
+	assert.Greater(t, values["nautical_twilight_duration"][0], values["civil_twilight_duration"][0])
+	total := values["day_length"][0] + values["civil_twilight_duration"][0] + values["nautical_twilight_duration"][0] + values["astronomical_twilight_duration"][0]
+	assert.InDelta(t, (23 * time.Hour).Seconds(), total, 3600)
+}
+
+func TestQueryDataDayLengthPolar(t *testing.T) {
+	// Longyearbyen on Svalbard
+	ds := &plugin.Datasource{Latitude: 78.22, Longitude: 15.65, Timezone: "Europe/Oslo"}
+
+	target := `{"target": ["day_length", "day_length_delta", "civil_twilight_duration", "nautical_twilight_duration", "astronomical_twilight_duration"]}`
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "day", JSON: []byte(target), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+			}},
+			{RefID: "night", JSON: []byte(target), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 12, 20, 23, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 12, 21, 23, 0, 0, 0, time.UTC),
+			}},
+			{RefID: "spring", JSON: []byte(`{"target": ["day_length"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 4, 20, 22, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 4, 21, 22, 0, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Midnight sun: the sun never sets and there is no twilight
+	day := dailyValues(t, resp.Responses["day"].Frames)
+	assert.Equal(t, []float64{86400}, day["day_length"])
+	assert.Equal(t, []float64{0}, day["day_length_delta"])
+	assert.Equal(t, []float64{0}, day["civil_twilight_duration"])
+	assert.Equal(t, []float64{0}, day["nautical_twilight_duration"])
+	assert.Equal(t, []float64{0}, day["astronomical_twilight_duration"])
+
+	// Polar night: no daylight, the sun stays below -6° but climbs above -18° at noon
+	night := dailyValues(t, resp.Responses["night"].Frames)
+	assert.Equal(t, []float64{0}, night["day_length"])
+	assert.Equal(t, []float64{0}, night["civil_twilight_duration"])
+	assert.Greater(t, night["nautical_twilight_duration"][0]+night["astronomical_twilight_duration"][0], 0.0)
+
+	// The midnight sun starts around April 20th
+	spring := dailyValues(t, resp.Responses["spring"].Frames)
+	assert.Equal(t, []float64{86400}, spring["day_length"])
+}
+
+func TestQueryDataTwilightSPAAltitude(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Vienna"}
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 3, 19, 23, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"engine": "spa", "target": ["day_length", "civil_twilight_duration"]}`), TimeRange: timeRange},
+			{RefID: "B", JSON: []byte(`{"engine": "spa", "target": ["sun_altitude"]}`), TimeRange: timeRange, Interval: 10 * time.Second, MaxDataPoints: 10000},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	durations := dailyValues(t, resp.Responses["A"].Frames)
+
+	// Refraction vanishes at -6°, the SPA altitude stays above it from dawn to dusk
+	altitude := resp.Responses["B"].Frames[0].Fields[1]
+	above := 0.0
+	for i := 0; i < altitude.Len(); i++ {
+		if altitude.At(i).(float64) > -6 {
+			above += 10
+		}
+	}
+	assert.Equal(t, 8640, altitude.Len())
+	assert.InDelta(t, above, durations["day_length"][0]+durations["civil_twilight_duration"][0], 20)
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// Lookback for the latest eclipse, several years can pass between two
+// eclipses visible from the same place
+const eclipseLookback = 10 * yearLookback
+
+// Eclipses happen in seasons about six months apart, the search for the
+// latest one goes back a season at a time and stops at the first hit
+const eclipseSeason = 183 * 24 * time.Hour
+
+// Margin around the range, the visible part of an eclipse starts at most this
+// long before or after its maximum
+const eclipseMargin = 12 * time.Hour
+
+// solarEclipseEvents finds the solar eclipses whose visible part starts
+// within the range, as regions spanning the part with the sun above the horizon
+func solarEclipseEvents(from time.Time, to time.Time, obs observer) []event {
+	events := []event{}
+	for _, eclipse := range astro.SolarEclipses(from.Add(-eclipseMargin), to.Add(eclipseMargin), obs.spa(from)) {
+		if !visibleWithin(eclipse.VisibleStart, from, to) {
+			continue
+		}
+
+		loc := from.Location()
+		events = append(events, event{
+			Time: eclipse.VisibleStart.In(loc),
+			End:  eclipse.VisibleEnd.In(loc),
+			Text: eclipseText(eclipse.Kind, "solar", eclipse.Magnitude, eclipse.Obscuration),
+			Values: []interface{}{
+				eclipse.Kind,
+				contactTime(eclipse.C1, loc),
+				contactTime(eclipse.C2, loc),
+				contactTime(eclipse.Maximum, loc),
+				contactTime(eclipse.C3, loc),
+				contactTime(eclipse.C4, loc),
+				eclipse.Magnitude,
+				eclipse.Obscuration,
+			},
+		})
+	}
+	return events
+}
+
+// solarEclipseFields are the fields of the solar eclipse frames after the tag
+func solarEclipseFields() []*data.Field {
+	return eclipseFields("C1", "C2", "Maximum", "C3", "C4")
+}
+
+// lunarEclipseEvents finds the lunar eclipses whose visible part starts
+// within the range, as regions spanning the part with the moon above the horizon
+func lunarEclipseEvents(from time.Time, to time.Time, obs observer) []event {
+	events := []event{}
+	for _, eclipse := range astro.LunarEclipses(from.Add(-eclipseMargin), to.Add(eclipseMargin), obs.spa(from)) {
+		if !visibleWithin(eclipse.VisibleStart, from, to) {
+			continue
+		}
+
+		loc := from.Location()
+		events = append(events, event{
+			Time: eclipse.VisibleStart.In(loc),
+			End:  eclipse.VisibleEnd.In(loc),
+			Text: eclipseText(eclipse.Kind, "lunar", eclipse.Magnitude, eclipse.Obscuration),
+			Values: []interface{}{
+				eclipse.Kind,
+				contactTime(eclipse.P1, loc),
+				contactTime(eclipse.U1, loc),
+				contactTime(eclipse.U2, loc),
+				contactTime(eclipse.Maximum, loc),
+				contactTime(eclipse.U3, loc),
+				contactTime(eclipse.U4, loc),
+				contactTime(eclipse.P4, loc),
+				eclipse.Magnitude,
+				eclipse.Obscuration,
+			},
+		})
+	}
+	return events
+}
+
+// lunarEclipseFields are the fields of the lunar eclipse frames after the tag
+func lunarEclipseFields() []*data.Field {
+	return eclipseFields("P1", "U1", "U2", "Maximum", "U3", "U4", "P4")
+}
+
+// eclipseFields returns the kind, the nullable contact time fields with the
+// given names, magnitude and obscuration
+func eclipseFields(contacts ...string) []*data.Field {
+	fields := []*data.Field{data.NewField("Kind", nil, []string{})}
+	for _, contact := range contacts {
+		fields = append(fields, data.NewField(contact, nil, []*time.Time{}))
+	}
+	return append(fields,
+		data.NewField("Magnitude", nil, []float64{}),
+		data.NewField("Obscuration", nil, []float64{}),
+	)
+}
+
+// visibleWithin reports whether the visible part of an eclipse exists and
+// starts at or after from and before to
+func visibleWithin(start time.Time, from time.Time, to time.Time) bool {
+	return !start.IsZero() && !start.Before(from) && start.Before(to)
+}
+
+// contactTime returns a contact in loc, or nil if it doesn't happen
+func contactTime(t time.Time, loc *time.Location) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.In(loc)
+	return &t
+}
+
+// eclipseText describes the kind and extent of an eclipse, like "Total lunar
+// eclipse, magnitude 1.36, 100% obscured"
+func eclipseText(kind string, body string, magnitude float64, obscuration float64) string {
+	return fmt.Sprintf("%s%s %s eclipse, magnitude %.2f, %.0f%% obscured", strings.ToUpper(kind[:1]), kind[1:], body, magnitude, obscuration*100)
+}
@@ -0,0 +1,82 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataEclipses(t *testing.T) {
+	// Dallas saw the total solar eclipse of 2024-04-08, Vienna didn't
+	dallas := &plugin.Datasource{Latitude: 32.7767, Longitude: -96.797}
+	vienna := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["solarEclipse", "lunarEclipse"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := dallas.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	solar := resp.Responses["A"].Frames[0]
+
+	assert.Equal(t, "TimeEnd", solar.Fields[1].Name)
+	assert.Equal(t, 1, solar.Rows())
+	assert.Equal(t, "Total solar eclipse, magnitude 1.02, 100% obscured", solar.Fields[3].At(0))
+	assert.Equal(t, "eclipse", solar.Fields[4].At(0))
+
+	row := map[string]interface{}{}
+	for _, field := range solar.Fields {
+		row[field.Name] = field.At(0)
+	}
+	assert.Equal(t, "total", row["Kind"])
+	assert.Equal(t, row["Time"], *row["C1"].(*time.Time))
+	assert.Equal(t, row["TimeEnd"], *row["C4"].(*time.Time))
+	assert.WithinDuration(t, time.Date(2024, 4, 8, 18, 42, 40, 0, time.UTC), *row["Maximum"].(*time.Time), 30*time.Second)
+	assert.WithinDuration(t, time.Date(2024, 4, 8, 18, 40, 44, 0, time.UTC), *row["C2"].(*time.Time), 30*time.Second)
+	assert.Equal(t, 1.0, row["Obscuration"])
+
+	// Alert rules get the time since the latest visible eclipse, half a year back
+	at := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	resp, err = dallas.QueryData(context.Background(), &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["solarEclipse"]}`), TimeRange: backend.TimeRange{From: at.Add(-time.Minute), To: at}},
+		},
+	})
+	assert.NoError(t, err)
+	since := resp.Responses["A"].Frames[0].Fields[0].At(0).(*float64)
+	if assert.NotNil(t, since) {
+		assert.InDelta(t, at.Sub(row["Time"].(time.Time)).Seconds(), *since, 1)
+	}
+
+	resp, err = vienna.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Responses["A"].Frames[0].Rows())
+
+	// The partial lunar eclipse of 2024-09-18 was visible in Vienna before moonset
+	lunar := resp.Responses["A"].Frames[1]
+	found := false
+	for i := 0; i < lunar.Rows(); i++ {
+		row := map[string]interface{}{}
+		for _, field := range lunar.Fields {
+			row[field.Name] = field.At(i)
+		}
+		if row["Kind"] != "partial" {
+			continue
+		}
+		found = true
+		assert.WithinDuration(t, time.Date(2024, 9, 18, 2, 44, 0, 0, time.UTC), *row["Maximum"].(*time.Time), 2*time.Minute)
+		assert.Nil(t, row["U2"])
+		assert.InDelta(t, 0.085, row["Magnitude"], 0.01)
+	}
+	assert.True(t, found)
+}
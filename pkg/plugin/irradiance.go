@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// Clear-sky irradiance models a query can choose from
+const (
+	clearSkyIneichen = "ineichen"
+	clearSkyHaurwitz = "haurwitz"
+)
+
+// Linke turbidity of a clear sky in rural mid-latitudes
+const defaultLinkeTurbidity = 3.0
+
+// clearSky returns the modelled irradiance under a cloudless sky at time t,
+// for the apparent sun altitude and the elevation of the observer
+func clearSky(t time.Time, obs observer) astro.Irradiance {
+	altitude, _ := sunPosition(t, obs)
+	zenith := 90 - obs.refract(altitude)
+	dniExtra := astro.ExtraterrestrialIrradiance(t)
+
+	if obs.ClearSkyModel == clearSkyHaurwitz {
+		return astro.ClearSkyHaurwitz(zenith, dniExtra)
+	}
+	return astro.ClearSkyIneichenPerez(zenith, obs.linkeTurbidity(), obs.Elevation, dniExtra)
+}
@@ -0,0 +1,94 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataClearSky(t *testing.T) {
+	turbidity := 4.0
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, LinkeTurbidity: &turbidity}
+
+	// Summer solstice from midnight to solar noon in Vienna, hourly
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC),
+	}
+	target := `"target": ["clear_sky_ghi", "clear_sky_dni", "clear_sky_dhi"]`
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "B", JSON: []byte(`{"linkeTurbidity": "2", ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "C", JSON: []byte(`{"clearSkyModel": "haurwitz", ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "D", JSON: []byte(`{"elevation": "2000", ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "E", JSON: []byte(`{"clearSkyModel": "solis", ` + target + `}`), TimeRange: timeRange},
+			{RefID: "F", JSON: []byte(`{"linkeTurbidity": "12", ` + target + `}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	frames := resp.Responses["A"].Frames
+	ghi, dni, dhi := frames[0].Fields[1], frames[1].Fields[1], frames[2].Fields[1]
+	assert.Equal(t, "Wm2", ghi.Config.Unit)
+
+	// Dark at midnight, close to 900 W/m² at local noon
+	assert.Equal(t, 0.0, ghi.At(0))
+	assert.InDelta(t, 880, ghi.At(13), 40)
+	assert.Greater(t, dni.At(13), dhi.At(13))
+
+	// A clearer sky, another model and a higher site change the values
+	assert.Greater(t, resp.Responses["B"].Frames[1].Fields[1].At(13), dni.At(13))
+	assert.NotEqual(t, ghi.At(13), resp.Responses["C"].Frames[0].Fields[1].At(13))
+	assert.Greater(t, resp.Responses["D"].Frames[0].Fields[1].At(13), ghi.At(13))
+
+	assert.ErrorContains(t, resp.Responses["E"].Error, "unknown clear-sky model: solis")
+	assert.ErrorContains(t, resp.Responses["F"].Error, "linke turbidity not in range 1 to 10: 12")
+}
+
+func TestQueryDataPlaneOfArray(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	// Summer solstice from sunrise to solar noon in Vienna, hourly
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 6, 21, 4, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC),
+	}
+	target := `"target": ["surface_aoi", "poa_irradiance", "tracker_rotation", "clear_sky_ghi"]`
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"surfaceTilt": "30", "surfaceAzimuth": "180", ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "B", JSON: []byte(`{"tracker": "singleAxis", ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "C", JSON: []byte(`{"tracker": "singleAxis", "groundCoverageRatio": 0, "maxAngle": 90, ` + target + `}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "D", JSON: []byte(`{"tracker": "dualAxis", ` + target + `}`), TimeRange: timeRange},
+			{RefID: "E", JSON: []byte(`{"surfaceTilt": "100", ` + target + `}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// A module tilted by 30° faces the noon sun almost straight on and gets more than the ground
+	fixed := resp.Responses["A"].Frames
+	assert.InDelta(t, 5.5, fixed[0].Fields[1].At(7), 1)
+	assert.Greater(t, fixed[1].Fields[1].At(7), fixed[3].Fields[1].At(7))
+	assert.Equal(t, 0.0, fixed[2].Fields[1].At(7))
+
+	// The tracker turns east in the morning, backtracks at sunrise and lies flat at noon
+	tracker := resp.Responses["B"].Frames
+	free := resp.Responses["C"].Frames
+	morning := tracker[2].Fields[1].At(2).(float64)
+	assert.Less(t, morning, -45.0)
+	assert.Greater(t, tracker[2].Fields[1].At(0), free[2].Fields[1].At(0))
+	assert.InDelta(t, 0, tracker[2].Fields[1].At(7), 3)
+	assert.Greater(t, tracker[1].Fields[1].At(2), fixed[1].Fields[1].At(2))
+
+	assert.ErrorContains(t, resp.Responses["D"].Error, "unknown tracker: dualAxis")
+	assert.ErrorContains(t, resp.Responses["E"].Error, "surface tilt not in range 0 to 90: 100")
+}
@@ -0,0 +1,190 @@
+package plugin
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/sixdouglas/suncalc"
+)
+
+// Sun phases returned by the sun_phase metric, ordered from dark to bright
+const (
+	sunPhaseNight = iota
+	sunPhaseAstronomicalTwilight
+	sunPhaseNauticalTwilight
+	sunPhaseCivilTwilight
+	sunPhaseDaylight
+)
+
+// Moon altitude in degrees at which suncalc reports moonrise and moonset
+const moonHorizonAngle = 0.133
+
+// metricValue computes the value of a metric at time t
+func metricValue(metric string, t time.Time, obs observer) float64 {
+	switch metric {
+	case "moon_illumination":
+		return suncalc.GetMoonIllumination(t).Fraction
+
+	case "moon_phase":
+		return moonPhase(t, obs)
+
+	case "moon_age":
+		return moonAge(t, obs)
+
+	case "moon_waxing":
+		return boolValue(moonWaxing(t, obs))
+
+	case "moon_phase_name":
+		return float64(moonPhaseName(t, obs))
+
+	case "moon_altitude":
+		// Apparent altitude in degrees for the atmosphere of the observer
+		return moonAltitude(t, obs)
+
+	case "moon_azimuth":
+		// Get the moon's azimuth (in radians) and convert it to degrees, adding 180 degrees
+		return suncalc.GetMoonPosition(t, obs.Latitude, obs.Longitude).Azimuth*(180/math.Pi) + 180
+
+	case "moon_distance":
+		// Get the distance to the moon in kilometers
+		return suncalc.GetMoonPosition(t, obs.Latitude, obs.Longitude).Distance
+
+	case "moon_above_horizon":
+		// Same criterion suncalc uses for moonrise and moonset
+		return boolValue(suncalc.GetMoonPosition(t, obs.Latitude, obs.Longitude).Altitude*(180/math.Pi) > moonHorizonAngle-obs.dip())
+
+	case "sun_altitude":
+		altitude, _ := sunPosition(t, obs)
+		return obs.refract(altitude)
+
+	case "sun_azimuth":
+		_, azimuth := sunPosition(t, obs)
+		return azimuth
+
+	case "sun_maximum_altitude":
+		// Get the solar noon time of the local day, then calculate the sun's altitude at solar noon
+		noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+		_, solarNoon, _ := sunRiseNoonSet(noon, obs)
+		altitude, _ := sunPosition(solarNoon, obs)
+		return obs.refract(altitude)
+
+	case "clear_sky_ghi":
+		return clearSky(t, obs).GHI
+
+	case "clear_sky_dni":
+		return clearSky(t, obs).DNI
+
+	case "clear_sky_dhi":
+		return clearSky(t, obs).DHI
+
+	case "surface_aoi":
+		_, aoi := planeOfArray(t, obs, obs.Surface)
+		return aoi
+
+	case "poa_irradiance":
+		poa, _ := planeOfArray(t, obs, obs.Surface)
+		return poa.Global
+
+	case "pv_expected_power":
+		return pvPower(t, obs)
+
+	case "pv_expected_energy_daily":
+		return pvEnergyDaily(t, obs)
+
+	case "tracker_rotation":
+		altitude, azimuth := sunPosition(t, obs)
+		rotation, _, _ := obs.Surface.orientation(90-obs.refract(altitude), azimuth, obs.Latitude)
+		return rotation
+
+	case "day_length":
+		return dayLength(t, obs).Seconds()
+
+	case "day_length_delta":
+		// AddDate keeps the wall clock across DST changes
+		return (dayLength(t, obs) - dayLength(t.AddDate(0, 0, -1), obs)).Seconds()
+
+	case "civil_twilight_duration":
+		return twilightDuration(t, obs, sunriseAngle, civilTwilightAngle).Seconds()
+
+	case "nautical_twilight_duration":
+		return twilightDuration(t, obs, civilTwilightAngle, nauticalTwilightAngle).Seconds()
+
+	case "astronomical_twilight_duration":
+		return twilightDuration(t, obs, nauticalTwilightAngle, astronomicalTwilightAngle).Seconds()
+
+	case "sun_horizon_state":
+		return float64(sunHorizonState(t, obs))
+
+	case "moon_horizon_state":
+		return float64(moonHorizonState(t, obs))
+
+	case "sun_phase":
+		return float64(sunPhase(t, obs))
+
+	case "is_daylight":
+		return boolValue(sunPhase(t, obs) == sunPhaseDaylight)
+
+	case "is_civil_twilight":
+		return boolValue(sunPhase(t, obs) == sunPhaseCivilTwilight)
+
+	case "is_nautical_twilight":
+		return boolValue(sunPhase(t, obs) == sunPhaseNauticalTwilight)
+
+	case "is_astronomical_twilight":
+		return boolValue(sunPhase(t, obs) == sunPhaseAstronomicalTwilight)
+
+	case "is_night":
+		return boolValue(sunPhase(t, obs) == sunPhaseNight)
+	}
+
+	if value, ok := planetMetric(metric, t, obs); ok {
+		return value
+	}
+
+	return 0
+}
+
+// sunPhase classifies the sun altitude at time t using the same angles
+// suncalc uses for sunrise, dawn, nautical dawn and night end. Like the
+// annotations, the angles are lowered by the horizon dip.
+func sunPhase(t time.Time, obs observer) int {
+	altitude, _ := sunPosition(t, obs)
+	altitude += obs.dip()
+
+	switch {
+	case altitude > sunriseAngle:
+		return sunPhaseDaylight
+	case altitude > civilTwilightAngle:
+		return sunPhaseCivilTwilight
+	case altitude > nauticalTwilightAngle:
+		return sunPhaseNauticalTwilight
+	case altitude > astronomicalTwilightAngle:
+		return sunPhaseAstronomicalTwilight
+	default:
+		return sunPhaseNight
+	}
+}
+
+// boolValue converts a condition to 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// stateMappings maps each state value to its name, the index of a name is
+// its value. Returns nil for metrics without named states.
+func stateMappings(states []string) data.ValueMappings {
+	if len(states) == 0 {
+		return nil
+	}
+
+	mapper := data.ValueMapper{}
+	for i, state := range states {
+		mapper[strconv.Itoa(i)] = data.ValueMappingResult{Text: state, Index: i}
+	}
+	return data.ValueMappings{mapper}
+}
@@ -0,0 +1,62 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataStateMetrics(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"target": ["is_daylight", "is_civil_twilight", "is_nautical_twilight", "is_astronomical_twilight", "is_night", "sun_phase"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 12, 22, 0, 0, 0, 0, time.UTC),
+				},
+				Interval: 15 * time.Minute,
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 6)
+
+	// Exactly one of the boolean states is set at every step
+	phases := frames[5]
+	for i := 0; i < phases.Rows(); i++ {
+		sum := 0.0
+		for state, frame := range frames[:5] {
+			value := frame.Fields[1].At(i).(float64)
+			sum += value
+			if value == 1 {
+				// The boolean states are listed from bright to dark
+				assert.Equal(t, float64(4-state), phases.Fields[1].At(i))
+			}
+		}
+		assert.Equal(t, 1.0, sum)
+	}
+
+	// Midnight is night and noon is daylight on the winter solstice
+	assert.Equal(t, 1.0, frames[4].Fields[1].At(0))
+	assert.Equal(t, 1.0, frames[0].Fields[1].At(48))
+
+	// sun_phase carries names for State Timeline panels
+	mappings := phases.Fields[1].Config.Mappings
+	assert.Len(t, mappings, 1)
+	assert.Equal(t, "Daylight", mappings[0].(data.ValueMapper)["4"].Text)
+}
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// Default share of the way from apogee to perigee in percent a full or new
+// moon needs for a supermoon, following Richard Nolle's definition
+const defaultSupermoonThreshold = 90.0
+
+// Time around a syzygy searched for the perigee and apogee of its orbit
+const extremumWindow = 16 * 24 * time.Hour
+
+// perigeeEvents finds the perigees of the moon
+func perigeeEvents(from time.Time, to time.Time, obs observer) []event {
+	perigees, _ := astro.MoonDistanceExtrema(from, to, obs.spa(from).DeltaT)
+	return extremumEvents(perigees, from.Location(), "Moon is closest to earth at %.0f km")
+}
+
+// apogeeEvents finds the apogees of the moon
+func apogeeEvents(from time.Time, to time.Time, obs observer) []event {
+	_, apogees := astro.MoonDistanceExtrema(from, to, obs.spa(from).DeltaT)
+	return extremumEvents(apogees, from.Location(), "Moon is farthest from earth at %.0f km")
+}
+
+// extremumEvents turns perigees or apogees into events with the distance in the text
+func extremumEvents(extrema []astro.MoonExtremum, loc *time.Location, format string) []event {
+	events := []event{}
+	for _, extremum := range extrema {
+		events = append(events, event{Time: extremum.Time.In(loc), Text: fmt.Sprintf(format, extremum.Distance)})
+	}
+	return events
+}
+
+// supermoonEvents finds the full and new moons within the supermoon
+// threshold of the observer from the perigee, or from the apogee for micro
+// moons
+func supermoonEvents(micro bool) eventFinder {
+	return func(from time.Time, to time.Time, obs observer) []event {
+		deltaT := obs.spa(from).DeltaT
+		threshold := obs.supermoonThreshold()
+
+		syzygies := map[time.Time]string{}
+		for _, t := range astro.MoonPhases(astro.NewMoon, from, to, deltaT) {
+			syzygies[t] = "New moon"
+		}
+		for _, t := range astro.MoonPhases(astro.FullMoon, from, to, deltaT) {
+			syzygies[t] = "Full moon"
+		}
+
+		events := []event{}
+		for t, name := range syzygies {
+			perigees, apogees := astro.MoonDistanceExtrema(t.Add(-extremumWindow), t.Add(extremumWindow), deltaT)
+			perigee, apogee := nearestExtremum(perigees, t), nearestExtremum(apogees, t)
+			if perigee == nil || apogee == nil {
+				continue
+			}
+
+			distance := astro.MoonDistance(t, deltaT)
+			// Share of the way from apogee to perigee, 100 at the perigee
+			share := (apogee.Distance - distance) / (apogee.Distance - perigee.Distance) * 100
+			if !micro && share >= threshold {
+				events = append(events, event{Time: t.In(from.Location()), Text: fmt.Sprintf("%s at %.0f km, %.0f%% of the way to perigee", name, distance, share)})
+			}
+			if micro && share <= 100-threshold {
+				events = append(events, event{Time: t.In(from.Location()), Text: fmt.Sprintf("%s at %.0f km, %.0f%% of the way to apogee", name, distance, 100-share)})
+			}
+		}
+
+		sortEvents(events)
+		return events
+	}
+}
+
+// nearestExtremum returns the extremum closest in time to t, nil if there is none
+func nearestExtremum(extrema []astro.MoonExtremum, t time.Time) *astro.MoonExtremum {
+	var nearest *astro.MoonExtremum
+	for i := range extrema {
+		if nearest == nil || absDuration(extrema[i].Time.Sub(t)) < absDuration(nearest.Time.Sub(t)) {
+			nearest = &extrema[i]
+		}
+	}
+	return nearest
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,63 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataPerigeeAndSupermoon(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	year := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["perigee", "apogee", "supermoon", "micromoon"]}`), TimeRange: year},
+			{RefID: "B", JSON: []byte(`{"supermoonThreshold": 99, "target": ["supermoon"]}`), TimeRange: year},
+			{RefID: "C", JSON: []byte(`{"supermoonThreshold": 20, "target": ["supermoon"]}`), TimeRange: year},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+
+	perigee, apogee, supermoon, micromoon := frames[0], frames[1], frames[2], frames[3]
+	assert.Equal(t, 13, perigee.Rows())
+	assert.Equal(t, 14, apogee.Rows())
+
+	// The closest perigee of the year, the distance is in the text
+	assert.WithinDuration(t, time.Date(2024, 3, 10, 7, 3, 0, 0, time.UTC), perigee.Fields[0].At(2).(time.Time), 10*time.Minute)
+	assert.Contains(t, perigee.Fields[2].At(2), "Moon is closest to earth at 35689")
+
+	// The full moons from August to November were supermoons
+	fullMoons := []string{}
+	for i := 0; i < supermoon.Rows(); i++ {
+		text := supermoon.Fields[2].At(i).(string)
+		if text[:9] == "Full moon" {
+			fullMoons = append(fullMoons, supermoon.Fields[0].At(i).(time.Time).UTC().Format("2006-01-02"))
+		}
+	}
+	assert.Equal(t, []string{"2024-08-19", "2024-09-18", "2024-10-17", "2024-11-15"}, fullMoons)
+
+	// The full moon of February 24th was a micromoon
+	found := false
+	for i := 0; i < micromoon.Rows(); i++ {
+		if micromoon.Fields[0].At(i).(time.Time).UTC().Format("2006-01-02") == "2024-02-24" {
+			found = true
+			assert.Contains(t, micromoon.Fields[2].At(i), "Full moon at 40")
+		}
+	}
+	assert.True(t, found)
+
+	// A stricter threshold leaves fewer supermoons
+	assert.Less(t, resp.Responses["B"].Frames[0].Rows(), supermoon.Rows())
+	assert.ErrorContains(t, resp.Responses["C"].Error, "supermoon threshold not in range 50 to 100")
+}
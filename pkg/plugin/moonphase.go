@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"math"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// moonPhaseEvents finds the instants of a principal moon phase
+func moonPhaseEvents(phase astro.MoonPhase) eventFinder {
+	return func(from time.Time, to time.Time, obs observer) []event {
+		events := []event{}
+		for _, t := range astro.MoonPhases(phase, from, to, obs.spa(from).DeltaT) {
+			events = append(events, event{Time: t.In(from.Location())})
+		}
+		return events
+	}
+}
+
+// moonLunation returns the latest new moon at or before t and the next one
+// after t, the same instants as the newMoon annotation
+func moonLunation(t time.Time, obs observer) (time.Time, time.Time) {
+	var latest, next time.Time
+	for _, instant := range astro.MoonPhases(astro.NewMoon, t.Add(-monthLookback), t.Add(monthLookback), obs.spa(t).DeltaT) {
+		if !instant.After(t) {
+			latest = instant
+		} else if next.IsZero() {
+			next = instant
+		}
+	}
+	return latest, next
+}
+
+// moonAge returns the days passed since the latest new moon
+func moonAge(t time.Time, obs observer) float64 {
+	latest, _ := moonLunation(t, obs)
+	return t.Sub(latest).Hours() / 24
+}
+
+// moonPhase returns the fraction of the lunation passed at t (0 - 1), the age
+// of the moon divided by the time from the latest to the next new moon
+func moonPhase(t time.Time, obs observer) float64 {
+	latest, next := moonLunation(t, obs)
+	return t.Sub(latest).Seconds() / next.Sub(latest).Seconds()
+}
+
+// quarterLookback covers the longest time between two principal phases
+const quarterLookback = 9 * 24 * time.Hour
+
+// moonQuarter returns the latest principal phase at or before t and how far
+// the moon has moved on towards the next one (0 - 1), from the same instants
+// as the phase annotations
+func moonQuarter(t time.Time, obs observer) (astro.MoonPhase, float64) {
+	deltaT := obs.spa(t).DeltaT
+
+	var latest, next time.Time
+	var phase astro.MoonPhase
+	for _, p := range []astro.MoonPhase{astro.NewMoon, astro.FirstQuarter, astro.FullMoon, astro.LastQuarter} {
+		for _, instant := range astro.MoonPhases(p, t.Add(-quarterLookback), t.Add(quarterLookback), deltaT) {
+			if !instant.After(t) && instant.After(latest) {
+				latest, phase = instant, p
+			}
+			if instant.After(t) && (next.IsZero() || instant.Before(next)) {
+				next = instant
+			}
+		}
+	}
+
+	return phase, t.Sub(latest).Seconds() / next.Sub(latest).Seconds()
+}
+
+// moonWaxing reports whether the moon is between new moon and full moon
+func moonWaxing(t time.Time, obs observer) bool {
+	phase, _ := moonQuarter(t, obs)
+	return phase == astro.NewMoon || phase == astro.FirstQuarter
+}
+
+// moonPhaseName returns the index of the phase name among the eight phases,
+// each principal phase covers a quarter of the time to its neighbours
+// centered on its instant
+func moonPhaseName(t time.Time, obs observer) int {
+	phase, progress := moonQuarter(t, obs)
+	return (2*int(phase) + int(math.Floor(progress*2+0.5))) % 8
+}
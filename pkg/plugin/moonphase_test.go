@@ -0,0 +1,92 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataMoonPhases(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"target": ["newMoon", "firstQuarter", "fullMoon", "lastQuarter", "moon_phase", "moon_age", "moon_waxing", "moon_phase_name"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC),
+				},
+				Interval: 24 * time.Hour,
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 8)
+
+	// Metrics come first, then the annotations with the USNO instants of April 2024
+	phases := frames[4:]
+	expected := []time.Time{
+		time.Date(2024, 4, 8, 18, 21, 0, 0, time.UTC),
+		time.Date(2024, 4, 15, 19, 13, 0, 0, time.UTC),
+		time.Date(2024, 4, 23, 23, 49, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 11, 27, 0, 0, time.UTC),
+	}
+	for i, frame := range phases {
+		assert.Equal(t, 1, frame.Rows())
+		assert.WithinDuration(t, expected[i], frame.Fields[0].At(0).(time.Time), time.Minute)
+	}
+
+	// April 16th, the day after the first quarter
+	phase, age, waxing, name := frames[0].Fields[1], frames[1].Fields[1], frames[2].Fields[1], frames[3].Fields[1]
+	assert.InDelta(t, 7.2, age.At(8), 0.1)
+	// The lunation runs from 2024-04-08 18:21 to 2024-05-08 03:22 UTC, 29.375 days
+	assert.InDelta(t, age.At(8).(float64)/29.375, phase.At(8), 0.0005)
+	assert.Equal(t, 1.0, waxing.At(8))
+	assert.Equal(t, "First Quarter", name.At(8))
+	assert.Equal(t, data.FieldTypeString, name.Type())
+
+	// April 27th, a few days after the full moon
+	assert.Equal(t, 0.0, waxing.At(19))
+	assert.Equal(t, "Waning Gibbous", name.At(19))
+	assert.InDelta(t, 18.2, age.At(19), 0.1)
+}
+
+func TestQueryDataMoonWaxingAtFullMoon(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	// The moon turns waning at the instant of the fullMoon annotation, 2024-04-23 23:49 UTC
+	fullMoon := time.Date(2024, 4, 23, 23, 49, 0, 0, time.UTC)
+	query := func(refID string, at time.Time) backend.DataQuery {
+		return backend.DataQuery{
+			RefID:     refID,
+			JSON:      []byte(`{"target": ["moon_waxing", "moon_phase_name"]}`),
+			TimeRange: backend.TimeRange{From: at, To: at.Add(time.Second)},
+			Interval:  time.Second,
+		}
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			query("before", fullMoon.Add(-5*time.Minute)),
+			query("after", fullMoon.Add(5*time.Minute)),
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	before, after := resp.Responses["before"].Frames, resp.Responses["after"].Frames
+	assert.Equal(t, 1.0, before[0].Fields[1].At(0))
+	assert.Equal(t, 0.0, after[0].Fields[1].At(0))
+	assert.Equal(t, "Full Moon", before[1].Fields[1].At(0))
+	assert.Equal(t, "Full Moon", after[1].Fields[1].At(0))
+}
@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/sixdouglas/suncalc"
+)
+
+// Solar position engines a query can choose from
+const (
+	engineSuncalc = "suncalc"
+	engineSPA     = "spa"
+)
+
+// observer is the location and the settings of a query that the sun and
+// moon computations depend on
+type observer struct {
+	Latitude    float64
+	Longitude   float64
+	Elevation   float64  // Height above sea level in meters
+	Pressure    *float64 // Air pressure in mbar, standard atmosphere if not set
+	Temperature *float64 // Air temperature in °C, standard atmosphere if not set
+	Engine      string   // Solar position engine, empty means suncalc
+	DeltaT      *float64 // TT - UT in seconds, estimated if not set
+
+	SupermoonThreshold *float64 // Percent of the way from apogee to perigee for a supermoon
+
+	LinkeTurbidity *float64 // Linke turbidity of the clear sky, defaultLinkeTurbidity if not set
+	ClearSkyModel  string   // Clear-sky irradiance model, empty means Ineichen-Perez
+
+	Surface surface // Tilted plane for the angle of incidence and the plane-of-array irradiance
+
+	PVArrays []models.PVArray // PV arrays for the expected power
+
+	AltitudeCross altitudeCross // Sun altitude and direction of the sunAltitudeCross annotation
+}
+
+// validate checks the settings of the observer
+func (o observer) validate() error {
+	switch o.Engine {
+	case "", engineSuncalc, engineSPA:
+	default:
+		return fmt.Errorf("unknown engine: %s", o.Engine)
+	}
+
+	if o.Pressure != nil && *o.Pressure <= 0 {
+		return fmt.Errorf("pressure must be above 0 mbar: %g", *o.Pressure)
+	}
+	if o.Temperature != nil && *o.Temperature <= -273.15 {
+		return fmt.Errorf("temperature must be above -273.15 °C: %g", *o.Temperature)
+	}
+	if o.SupermoonThreshold != nil && (*o.SupermoonThreshold < 50 || *o.SupermoonThreshold > 100) {
+		return fmt.Errorf("supermoon threshold not in range 50 to 100: %g", *o.SupermoonThreshold)
+	}
+
+	switch o.ClearSkyModel {
+	case "", clearSkyIneichen, clearSkyHaurwitz:
+	default:
+		return fmt.Errorf("unknown clear-sky model: %s", o.ClearSkyModel)
+	}
+	if o.LinkeTurbidity != nil && (*o.LinkeTurbidity < 1 || *o.LinkeTurbidity > 10) {
+		return fmt.Errorf("linke turbidity not in range 1 to 10: %g", *o.LinkeTurbidity)
+	}
+	if err := o.Surface.validate(); err != nil {
+		return err
+	}
+	if err := o.AltitudeCross.validate(); err != nil {
+		return err
+	}
+	return models.ValidatePVArrays(o.PVArrays)
+}
+
+// pressure returns the air pressure in mbar
+func (o observer) pressure() float64 {
+	if o.Pressure != nil {
+		return *o.Pressure
+	}
+	return astro.StandardPressure
+}
+
+// temperature returns the air temperature in °C
+func (o observer) temperature() float64 {
+	if o.Temperature != nil {
+		return *o.Temperature
+	}
+	return astro.StandardTemperature
+}
+
+// supermoonThreshold returns the supermoon threshold in percent
+func (o observer) supermoonThreshold() float64 {
+	if o.SupermoonThreshold != nil {
+		return *o.SupermoonThreshold
+	}
+	return defaultSupermoonThreshold
+}
+
+// linkeTurbidity returns the Linke turbidity of the clear sky
+func (o observer) linkeTurbidity() float64 {
+	if o.LinkeTurbidity != nil {
+		return *o.LinkeTurbidity
+	}
+	return defaultLinkeTurbidity
+}
+
+// dip returns how far the visible horizon lies below the astronomical
+// horizon for the elevation of the observer, in degrees
+func (o observer) dip() float64 {
+	return astro.HorizonDip(o.Elevation)
+}
+
+// refract turns a true altitude in degrees into the apparent altitude seen
+// through the atmosphere of the observer
+func (o observer) refract(altitude float64) float64 {
+	return altitude + astro.Refraction(altitude, o.pressure(), o.temperature())
+}
+
+// suncalc returns the suncalc input for the day in the given location
+func (o observer) suncalc(loc *time.Location) suncalc.Observer {
+	return suncalc.Observer{Latitude: o.Latitude, Longitude: o.Longitude, Height: math.Max(o.Elevation, 0), Location: loc}
+}
+
+// spa returns the SPA input for computations at time t
+func (o observer) spa(t time.Time) astro.Observer {
+	obs := astro.NewObserver(o.Latitude, o.Longitude)
+	obs.Elevation = o.Elevation
+	obs.Pressure = o.pressure()
+	obs.Temperature = o.temperature()
+	obs.DeltaT = astro.DeltaT(t)
+	if o.DeltaT != nil {
+		obs.DeltaT = *o.DeltaT
+	}
+	return obs
+}
+
+// sunPosition returns the true altitude of the sun and its azimuth measured
+// clockwise from north, both in degrees. Use observer.refract for the
+// apparent altitude.
+func sunPosition(t time.Time, obs observer) (float64, float64) {
+	if obs.Engine == engineSPA {
+		p := astro.SolarPositionSPA(t, obs.spa(t))
+		return p.TrueElevation, p.Azimuth
+	}
+
+	p := suncalc.GetPosition(t, obs.Latitude, obs.Longitude)
+	return p.Altitude * (180 / math.Pi), p.Azimuth*(180/math.Pi) + 180
+}
+
+// sunRiseNoonSet returns sunrise, solar noon and sunset around the given
+// local noon. Sunrise and sunset are zero if the sun doesn't rise or set.
+// Both engines lower the horizon by the dip for the observer elevation.
+func sunRiseNoonSet(noon time.Time, obs observer) (time.Time, time.Time, time.Time) {
+	if obs.Engine == engineSPA {
+		return astro.SunRiseTransitSetSPA(noon, obs.spa(noon))
+	}
+
+	solarTimes := sunTimes(noon, obs)
+	return solarTimes[suncalc.Sunrise].Value, solarTimes[suncalc.SolarNoon].Value, solarTimes[suncalc.Sunset].Value
+}
+
+// sunTimes returns the suncalc sun times around the given local noon. The
+// times the sun doesn't reach are zero: suncalc converts them from NaN,
+// which gives the zero time on some platforms and 1970 on others.
+func sunTimes(noon time.Time, obs observer) map[suncalc.DayTimeName]suncalc.DayTime {
+	solarTimes := suncalc.GetTimesWithObserver(noon, obs.suncalc(noon.Location()))
+	for name, dayTime := range solarTimes {
+		if offset := dayTime.Value.Sub(noon); offset > 24*time.Hour || offset < -24*time.Hour {
+			solarTimes[name] = suncalc.DayTime{Name: name}
+		}
+	}
+	return solarTimes
+}
+
+// moonAltitude returns the apparent altitude of the moon in degrees. suncalc
+// applies a fixed refraction that is replaced by the one for the atmosphere
+// of the observer.
+func moonAltitude(t time.Time, obs observer) float64 {
+	apparent := suncalc.GetMoonPosition(t, obs.Latitude, obs.Longitude).Altitude
+
+	// Undo the suncalc refraction, a few iterations converge well below an arc second
+	altitude := apparent
+	for i := 0; i < 5; i++ {
+		altitude = apparent - suncalcRefraction(altitude)
+	}
+
+	return obs.refract(altitude * (180 / math.Pi))
+}
+
+// suncalcRefraction is the refraction in radians suncalc adds to the moon
+// altitude, given the true altitude in radians
+func suncalcRefraction(h float64) float64 {
+	if h < 0 {
+		h = 0
+	}
+	return 0.0002967 / math.Tan(h+0.00312536/(h+0.08901179))
+}
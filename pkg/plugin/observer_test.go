@@ -0,0 +1,99 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataEngine(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  39.742476,
+		Longitude: -105.1786,
+	}
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2003, 10, 17, 19, 30, 30, 0, time.UTC),
+		To:   time.Date(2003, 10, 17, 19, 31, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["sun_altitude", "sun_azimuth"]}`), TimeRange: timeRange},
+			{RefID: "B", JSON: []byte(`{"engine": "spa", "deltaT": 67, "target": ["sun_altitude", "sun_azimuth"]}`), TimeRange: timeRange},
+			{RefID: "C", JSON: []byte(`{"engine": "vsop87", "target": ["sun_altitude"]}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	observer := astro.NewObserver(ds.Latitude, ds.Longitude)
+	observer.DeltaT = 67
+	spa := astro.SolarPositionSPA(timeRange.From, observer)
+
+	spaFrames := resp.Responses["B"].Frames
+	assert.InDelta(t, spa.Elevation, spaFrames[0].Fields[1].At(0), 1e-9)
+	assert.InDelta(t, spa.Azimuth, spaFrames[1].Fields[1].At(0), 1e-9)
+
+	// suncalc drifts by a fraction of a degree
+	suncalcFrames := resp.Responses["A"].Frames
+	assert.InDelta(t, spa.Elevation, suncalcFrames[0].Fields[1].At(0), 0.5)
+	assert.NotEqual(t, spa.Elevation, suncalcFrames[0].Fields[1].At(0))
+	assert.InDelta(t, spa.Azimuth, suncalcFrames[1].Fields[1].At(0), 0.5)
+
+	assert.ErrorContains(t, resp.Responses["C"].Error, "unknown engine: vsop87")
+}
+
+func TestQueryDataObserverElevation(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  47.37,
+		Longitude: 8.54,
+		Timezone:  "Europe/Zurich",
+	}
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC),
+	}
+	query := func(refID string, json string) backend.DataQuery {
+		return backend.DataQuery{RefID: refID, JSON: []byte(json), TimeRange: timeRange, Interval: time.Hour}
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			query("A", `{"target": ["sunrise", "sun_altitude"]}`),
+			query("B", `{"elevation": "2000", "target": ["sunrise", "sun_altitude"]}`),
+			query("C", `{"pressure": "700", "temperature": "-10", "target": ["sun_altitude"]}`),
+			query("D", `{"elevation": "high", "target": ["sunrise"]}`),
+			query("E", `{"pressure": "-1", "target": ["sunrise"]}`),
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	seaLevel := resp.Responses["A"].Frames
+	mountain := resp.Responses["B"].Frames
+
+	// The lower horizon from 2000 m lets the sun rise several minutes earlier
+	sunrise := seaLevel[1].Fields[0].At(0).(time.Time)
+	earlier := sunrise.Sub(mountain[1].Fields[0].At(0).(time.Time))
+	assert.Greater(t, earlier, 5*time.Minute)
+	assert.Less(t, earlier, 15*time.Minute)
+
+	// The apparent altitude doesn't depend on the elevation
+	assert.Equal(t, seaLevel[0].Fields[1].At(8), mountain[0].Fields[1].At(8))
+
+	// Thin cold air refracts less near the horizon, at 06:00 UTC the sun is at about 4.5°
+	standard := seaLevel[0].Fields[1].At(6).(float64)
+	thin := resp.Responses["C"].Frames[0].Fields[1].At(6).(float64)
+	assert.Greater(t, standard, thin)
+	assert.InDelta(t, standard, thin, 0.2)
+
+	assert.ErrorContains(t, resp.Responses["D"].Error, "invalid elevation")
+	assert.ErrorContains(t, resp.Responses["E"].Error, "pressure must be above 0 mbar")
+}
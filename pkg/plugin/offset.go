@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// parseAnnotationTarget splits an annotation target into the annotation and
+// a signed offset appended as a Go duration, e.g. sunset-30m or sunrise+1h.
+// Reports false if the target doesn't name an annotation.
+func parseAnnotationTarget(target string) (string, time.Duration, bool) {
+	if _, ok := models.SunAndMoonAnnotations[target]; ok {
+		return target, 0, true
+	}
+
+	// Annotation names don't contain a sign, the last one starts the offset
+	i := strings.LastIndexAny(target, "+-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	annotation := target[:i]
+	if _, ok := models.SunAndMoonAnnotations[annotation]; !ok {
+		return "", 0, false
+	}
+	offset, err := time.ParseDuration(target[i:])
+	if err != nil || offset == 0 {
+		return "", 0, false
+	}
+	return annotation, offset, true
+}
+
+// offsetDefinition returns the annotation definition with the offset in
+// its title and text, e.g. "Sunset -30m" and "30m before Sunset: ..."
+func offsetDefinition(def models.AnnotationDefinition, offset time.Duration) models.AnnotationDefinition {
+	if offset == 0 {
+		return def
+	}
+	def.Text = offsetText(def, def.Text, offset)
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+	}
+	def.Title = fmt.Sprintf("%s %s%s", def.Title, sign, formatOffset(offset))
+	return def
+}
+
+// offsetText prefixes the text of an event with its offset, def is the
+// definition without the offset
+func offsetText(def models.AnnotationDefinition, text string, offset time.Duration) string {
+	if offset == 0 {
+		return text
+	}
+	if offset < 0 {
+		return fmt.Sprintf("%s before %s: %s", formatOffset(offset), def.Title, text)
+	}
+	return fmt.Sprintf("%s after %s: %s", formatOffset(offset), def.Title, text)
+}
+
+// formatOffset formats the length of an offset without zero units, 1h
+// instead of 1h0m0s
+func formatOffset(offset time.Duration) string {
+	if offset < 0 {
+		offset = -offset
+	}
+	s := offset.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
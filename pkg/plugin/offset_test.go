@@ -0,0 +1,84 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataEventOffsets(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Vienna"}
+
+	days := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 22, 22, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["sunset", "sunset-30m", "sunrise+1h30m", "fullNight+1h", "juneSolstice+2h"]}`), TimeRange: days},
+			{RefID: "B", JSON: []byte(`{"target": ["sunset-30x"]}`), TimeRange: days},
+			{RefID: "C", JSON: []byte(`{"target": ["sunset-30m"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 21, 18, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 21, 18, 30, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	frames := resp.Responses["A"].Frames
+	sunset, before, after, night, solstice := frames[0], frames[1], frames[2], frames[3], frames[4]
+
+	// Events are found for the days of the shifted range, which ends on the day after
+	assert.Equal(t, 3, before.Rows())
+	assert.Equal(t, sunset.Fields[0].At(0).(time.Time).Add(-30*time.Minute), before.Fields[0].At(0))
+	assert.Equal(t, "Sunset -30m", before.Name)
+	assert.Equal(t, "Sunset -30m", before.Fields[1].At(0))
+	assert.Contains(t, before.Fields[2].At(0), "30m before Sunset: ")
+
+	assert.Equal(t, "Sunrise +1h30m", after.Fields[1].At(0))
+	assert.Contains(t, after.Fields[2].At(0), "1h30m after Sunrise: ")
+
+	// Regions shift both ends
+	assert.Equal(t, "Night +1h", night.Fields[2].At(0))
+	assert.Greater(t, night.Rows(), 0)
+
+	// Range events shift as well, the solstice at 20:51 UTC lies just before the range
+	assert.Equal(t, 1, solstice.Rows())
+	assert.WithinDuration(t, time.Date(2024, 6, 20, 22, 51, 0, 0, time.UTC), solstice.Fields[0].At(0).(time.Time), 2*time.Minute)
+
+	assert.ErrorContains(t, resp.Responses["B"].Error, "unknown target: sunset-30x")
+
+	// The sunset half an hour later than the end of the range still shows up shifted into it
+	assert.Equal(t, 1, resp.Responses["C"].Frames[0].Rows())
+}
+
+func TestQueryDataEventOffsetAlerting(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Vienna"}
+
+	req := &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["sunset", "sunset-30m"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 21, 17, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 21, 18, 45, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Sunset at about 18:58 UTC is still ahead, half an hour before it already passed
+	frames := resp.Responses["A"].Frames
+	sunset := *frames[0].Fields[0].At(0).(*float64)
+	before := *frames[1].Fields[0].At(0).(*float64)
+	assert.Greater(t, sunset, 12*3600.0)
+	assert.Less(t, before, 3600.0)
+	assert.Equal(t, "sunset-30m", frames[1].Fields[0].Labels["target"])
+}
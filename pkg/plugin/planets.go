@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// planetMetric computes the metrics of models.PlanetMetrics, ok is false for
+// any other metric
+func planetMetric(metric string, t time.Time, obs observer) (float64, bool) {
+	target, ok := models.PlanetMetrics[metric]
+	if !ok {
+		return 0, false
+	}
+
+	p := astro.PlanetaryPosition(target.Planet, t, obs.spa(t))
+	switch target.Kind {
+	case "altitude":
+		return p.Elevation, true
+	case "azimuth":
+		return p.Azimuth, true
+	case "distance":
+		return p.Distance * astro.AstronomicalUnit, true
+	case "magnitude":
+		return p.Magnitude, true
+	case "elongation":
+		return p.Elongation, true
+	}
+
+	return 0, false
+}
+
+// planetScanKey identifies a walk over a time range for one planet
+type planetScanKey struct {
+	planet astro.Planet
+	from   int64
+	to     int64
+}
+
+// planetScans holds the rises, transits and sets found while answering a
+// query for one site, so the annotations of a planet share a single walk
+// over the range
+type planetScans map[planetScanKey][3][]time.Time
+
+// rangeEvent returns the range event of a planet annotation of
+// models.PlanetAnnotations, finding its events in the scans
+func (s planetScans) rangeEvent(target models.PlanetTarget) rangeEvent {
+	find := func(from time.Time, to time.Time, obs observer) []event {
+		key := planetScanKey{planet: target.Planet, from: from.UnixNano(), to: to.UnixNano()}
+		scan, ok := s[key]
+		if !ok {
+			rises, transits, sets := astro.PlanetEvents(target.Planet, from, to, obs.spa(from))
+			scan = [3][]time.Time{rises, transits, sets}
+			s[key] = scan
+		}
+
+		var times []time.Time
+		switch target.Kind {
+		case "Rise":
+			times = scan[0]
+		case "Transit":
+			times = scan[1]
+		case "Set":
+			times = scan[2]
+		}
+
+		events := []event{}
+		for _, t := range times {
+			events = append(events, event{Time: t.In(from.Location())})
+		}
+		return events
+	}
+	return rangeEvent{find: find, lookback: alertLookback * 24 * time.Hour}
+}
@@ -0,0 +1,82 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataPlanets(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  48.2,
+		Longitude: 16.4,
+		Timezone:  "Europe/Vienna",
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"target": ["jupiter_altitude", "jupiter_distance", "venus_magnitude", "marsRise", "marsTransit", "marsSet"]}`),
+				TimeRange: backend.TimeRange{
+					From: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+				},
+				Interval: time.Hour,
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 6)
+
+	altitude := astro.PlanetaryPosition(astro.Jupiter, req.Queries[0].TimeRange.From, astro.NewObserver(48.2, 16.4))
+	assert.InDelta(t, altitude.Elevation, frames[0].Fields[1].At(0), 0.01)
+	assert.Equal(t, 48, frames[0].Fields[1].Len())
+
+	// Jupiter is about 6 astronomical units away around its conjunction
+	assert.InDelta(t, 6*astro.AstronomicalUnit, frames[1].Fields[1].At(0), 0.05*astro.AstronomicalUnit)
+
+	// Venus is near its superior conjunction, fainter than as a morning or evening star
+	assert.InDelta(t, -3.9, frames[2].Fields[1].At(0), 0.1)
+
+	// Mars rises, transits and sets once on each of the two days in the range
+	for _, frame := range frames[3:] {
+		assert.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "mars", frame.Fields[3].At(0))
+	}
+	rise := frames[3].Fields[0].At(0).(time.Time)
+	transit := frames[4].Fields[0].At(0).(time.Time)
+	set := frames[5].Fields[0].At(0).(time.Time)
+	assert.True(t, rise.Before(transit))
+	assert.True(t, transit.Before(set))
+}
+
+func TestQueryDataPlanetsRange(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4}
+
+	ranges := map[string]backend.TimeRange{
+		"A": {From: time.Date(1700, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(1700, 6, 2, 0, 0, 0, 0, time.UTC)},
+		"B": {From: time.Date(2100, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2100, 6, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	req := &backend.QueryDataRequest{}
+	for refID, timeRange := range ranges {
+		req.Queries = append(req.Queries, backend.DataQuery{RefID: refID, JSON: []byte(`{"target": ["saturn_altitude", "marsRise"]}`), TimeRange: timeRange})
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The VSOP87 series aren't bound to a range of years
+	for refID := range ranges {
+		assert.NoError(t, resp.Responses[refID].Error, refID)
+		assert.Len(t, resp.Responses[refID].Frames, 2, refID)
+	}
+}
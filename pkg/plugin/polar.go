@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// Horizon states of the sun and the moon over a local day, values of the
+// horizon state metrics
+const (
+	horizonRisesOrSets = iota
+	horizonAlwaysUp
+	horizonAlwaysDown
+)
+
+// Longest stretch of polar day or polar night, reached at the poles
+const polarStretch = 186
+
+// sunHorizonState tells whether the sun rises or sets on the local day of t,
+// or stays above or below the horizon all day long. Days with only a sunrise
+// or only a sunset, at the start and the end of a polar stretch, count as
+// rising or setting.
+func sunHorizonState(t time.Time, obs observer) int {
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+	sunrise, solarNoon, sunset := sunRiseNoonSet(noon, obs)
+	if !sunrise.IsZero() || !sunset.IsZero() {
+		return horizonRisesOrSets
+	}
+
+	if altitude, _ := sunPosition(solarNoon, obs); altitude+obs.dip() > sunriseAngle {
+		return horizonAlwaysUp
+	}
+	return horizonAlwaysDown
+}
+
+// moonHorizonState tells whether the moon rises or sets on the local day of t,
+// or stays above or below the horizon all day long
+func moonHorizonState(t time.Time, obs observer) int {
+	moonTimes := suncalc.GetMoonTimesWithObserver(t, obs.suncalc(t.Location()))
+	switch {
+	case moonTimes.AlwaysUp:
+		return horizonAlwaysUp
+	case moonTimes.AlwaysDown:
+		return horizonAlwaysDown
+	default:
+		return horizonRisesOrSets
+	}
+}
+
+// polarEvents finds the stretches of local days on which the sun stays above
+// the horizon (horizonAlwaysUp) or below it (horizonAlwaysDown), as regions
+// from the first midnight to the midnight after the last day. Unlike other
+// finders it returns every stretch overlapping the range, a stretch in
+// progress at from starts before it.
+func polarEvents(state int) eventFinder {
+	return func(from time.Time, to time.Time, obs observer) []event {
+		day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+		// Go back to the first day of a stretch in progress
+		for i := 0; i < polarStretch && sunHorizonState(day, obs) == state; i++ {
+			previous := day.AddDate(0, 0, -1)
+			if sunHorizonState(previous, obs) != state {
+				break
+			}
+			day = previous
+		}
+
+		// A stretch still going at to is followed past it
+		limit := to.AddDate(0, 0, polarStretch)
+
+		events := []event{}
+		var start time.Time
+		days := 0
+		for ; day.Before(limit); day = day.AddDate(0, 0, 1) {
+			if sunHorizonState(day, obs) == state {
+				if start.IsZero() {
+					if !day.Before(to) {
+						break
+					}
+					start, days = day, 0
+				}
+				days++
+				continue
+			}
+
+			if !start.IsZero() {
+				events = append(events, event{Time: start, End: day, Text: polarText(state, days)})
+				start = time.Time{}
+			}
+			if !day.Before(to) {
+				break
+			}
+		}
+		if !start.IsZero() {
+			events = append(events, event{Time: start, End: day, Text: polarText(state, days)})
+		}
+		return events
+	}
+}
+
+// polarText describes a stretch of polar day or polar night
+func polarText(state int, days int) string {
+	verb := "set"
+	if state == horizonAlwaysDown {
+		verb = "rise"
+	}
+	if days == 1 {
+		return fmt.Sprintf("The sun doesn't %s for 1 day", verb)
+	}
+	return fmt.Sprintf("The sun doesn't %s for %d days", verb, days)
+}
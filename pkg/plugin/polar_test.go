@@ -0,0 +1,118 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataPolarDayAndNight(t *testing.T) {
+	// Longyearbyen on Svalbard
+	ds := &plugin.Datasource{Latitude: 78.22, Longitude: 15.65, Timezone: "Europe/Oslo"}
+	oslo, _ := time.LoadLocation("Europe/Oslo")
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["polarDay", "polarNight", "sunrise", "sunset", "fullNight"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			{RefID: "B", JSON: []byte(`{"target": ["polarNight", "sunrise"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The midnight sun started before the range and lasts past it
+	frames := resp.Responses["A"].Frames
+	polarDay, polarNight, sunrise, sunset, night := frames[0], frames[1], frames[2], frames[3], frames[4]
+	assert.Equal(t, 1, polarDay.Rows())
+	assert.Equal(t, time.Date(2024, 4, 19, 0, 0, 0, 0, oslo), polarDay.Fields[0].At(0))
+	assert.Equal(t, time.Date(2024, 8, 25, 0, 0, 0, 0, oslo), polarDay.Fields[1].At(0))
+	assert.Equal(t, "The sun doesn't set for 128 days", polarDay.Fields[3].At(0))
+	assert.Equal(t, 0, polarNight.Rows())
+	assert.Equal(t, 0, sunrise.Rows())
+	assert.Equal(t, 0, sunset.Rows())
+	assert.Equal(t, 0, night.Rows())
+
+	// The polar night at the start of the year began in the previous one
+	frames = resp.Responses["B"].Frames
+	polarNight, sunrise = frames[0], frames[1]
+	assert.Equal(t, 2, polarNight.Rows())
+	assert.Equal(t, time.Date(2023, 10, 27, 0, 0, 0, 0, oslo), polarNight.Fields[0].At(0))
+	assert.Equal(t, time.Date(2024, 2, 16, 0, 0, 0, 0, oslo), polarNight.Fields[1].At(0))
+	assert.Equal(t, "The sun doesn't rise for 112 days", polarNight.Fields[3].At(0))
+	assert.Equal(t, time.Date(2024, 10, 27, 0, 0, 0, 0, oslo), polarNight.Fields[0].At(1))
+
+	// Sunrises only happen between the polar nights and the polar day, never at an invalid time
+	for i := 0; i < sunrise.Rows(); i++ {
+		rise := sunrise.Fields[0].At(i).(time.Time)
+		assert.Equal(t, 2024, rise.Year())
+		inPolarStretch := !rise.Before(time.Date(2024, 4, 19, 0, 0, 0, 0, oslo)) && rise.Before(time.Date(2024, 8, 25, 0, 0, 0, 0, oslo))
+		assert.False(t, inPolarStretch, rise)
+	}
+	assert.Greater(t, sunrise.Rows(), 100)
+}
+
+func TestQueryDataHorizonStates(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 78.22, Longitude: 15.65, Timezone: "Europe/Oslo"}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["sun_horizon_state", "moon_horizon_state", "day_length"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 11, 30, 23, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC),
+			}, Interval: time.Hour},
+			{RefID: "B", JSON: []byte(`{"target": ["sun_horizon_state", "sun_maximum_altitude"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+			}, Interval: time.Hour},
+			{RefID: "C", JSON: []byte(`{"target": ["sun_maximum_altitude"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 12, 20, 23, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 12, 21, 23, 0, 0, 0, time.UTC),
+			}, Interval: time.Hour},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// December is polar night, the moon circles above or below the horizon for days
+	values := dailyValues(t, resp.Responses["A"].Frames)
+	assert.Len(t, values["sun_horizon_state"], 31)
+	moonStates := map[float64]int{}
+	for i := range values["sun_horizon_state"] {
+		assert.Equal(t, 2.0, values["sun_horizon_state"][i])
+		assert.Equal(t, 0.0, values["day_length"][i])
+		moonStates[values["moon_horizon_state"][i]]++
+	}
+	assert.Greater(t, moonStates[0], 0)
+	assert.Greater(t, moonStates[1], 0)
+	assert.Greater(t, moonStates[2], 0)
+
+	// The states are named
+	states := resp.Responses["A"].Frames[1].Fields[1].Config.Mappings[0].(data.ValueMapper)
+	assert.Equal(t, "Always up", states["1"].Text)
+
+	// The maximum altitude is the same all day, about 35 degrees at midsummer
+	frames := resp.Responses["B"].Frames
+	assert.Equal(t, 1.0, frames[0].Fields[1].At(0))
+	for i := 0; i < frames[1].Rows(); i++ {
+		assert.InDelta(t, 35.2, frames[1].Fields[1].At(i), 0.3)
+	}
+
+	// and below the horizon at the winter solstice
+	maximum := resp.Responses["C"].Frames[0]
+	for i := 0; i < maximum.Rows(); i++ {
+		assert.InDelta(t, -11.6, maximum.Fields[1].At(i), 0.3)
+	}
+}
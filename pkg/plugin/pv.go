@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// Defaults of a PV array
+const (
+	defaultTemperatureCoefficient = -0.4 // Power change in %/°C above 25 °C
+	defaultLosses                 = 14.0 // System losses in %, the PVWatts default
+)
+
+// Module temperature rise above the air in °C per W/m² of irradiance, from a
+// nominal operating cell temperature of 45 °C
+const cellTemperatureRise = (45.0 - 20.0) / 800
+
+// Time step of the integration of the daily energy
+const pvEnergyStep = 10 * time.Minute
+
+// pvArrays returns the PV arrays of the datasource, or only the one
+// with the given name if it's set
+func pvArrays(arrays []models.PVArray, name string) ([]models.PVArray, error) {
+	if name == "" {
+		return arrays, nil
+	}
+	for _, array := range arrays {
+		if array.Name == name {
+			return []models.PVArray{array}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown PV array: %s", name)
+}
+
+// checkPVArrays reports PV metrics queried without any PV array configured
+func checkPVArrays(metrics []string, obs observer) error {
+	if len(obs.PVArrays) > 0 {
+		return nil
+	}
+	for _, metric := range metrics {
+		if strings.HasPrefix(metric, "pv_") {
+			return fmt.Errorf("no PV arrays configured for %s", metric)
+		}
+	}
+	return nil
+}
+
+// pvPower returns the output of the PV arrays of the observer at time t in
+// kW. The PVWatts model scales the rated power with the clear-sky irradiance
+// on the modules and their temperature, then subtracts the system losses.
+func pvPower(t time.Time, obs observer) float64 {
+	power := 0.0
+	for _, array := range obs.PVArrays {
+		poa, _ := planeOfArray(t, obs, surface{Tilt: array.Tilt, Azimuth: array.Azimuth, Tracker: array.Tracker})
+
+		coefficient := defaultTemperatureCoefficient
+		if array.TemperatureCoefficient != nil {
+			coefficient = *array.TemperatureCoefficient
+		}
+		losses := defaultLosses
+		if array.Losses != nil {
+			losses = *array.Losses
+		}
+
+		cellTemperature := obs.temperature() + poa.Global*cellTemperatureRise
+		dc := array.Kwp * poa.Global / 1000 * (1 + coefficient/100*(cellTemperature-25))
+		power += math.Max(dc, 0) * (1 - losses/100)
+	}
+	return power
+}
+
+// pvEnergyDaily returns the yield of the PV arrays of the observer over the
+// local day of t in kWh
+func pvEnergyDaily(t time.Time, obs observer) float64 {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	end := day.AddDate(0, 0, 1)
+
+	// Trapezoidal rule, the power is zero at both ends of the night
+	energy := 0.0
+	previous := pvPower(day, obs)
+	for step := day.Add(pvEnergyStep); !step.After(end); step = step.Add(pvEnergyStep) {
+		power := pvPower(step, obs)
+		energy += (previous + power) / 2 * pvEnergyStep.Hours()
+		previous = power
+	}
+	return energy
+}
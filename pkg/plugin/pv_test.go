@@ -0,0 +1,82 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataPVExpected(t *testing.T) {
+	east, west := 90.0, 270.0
+	ds := &plugin.Datasource{
+		Latitude:  48.2,
+		Longitude: 16.4,
+		Timezone:  "Europe/Vienna",
+		PVArrays: []models.PVArray{
+			{Name: "roof", Kwp: 10, Tilt: 30},
+			{Name: "east", Kwp: 5, Tilt: 15, Azimuth: &east},
+			{Name: "west", Kwp: 5, Tilt: 15, Azimuth: &west},
+		},
+	}
+
+	// Two days around the summer solstice in Vienna
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 22, 22, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"target": ["pv_expected_power", "pv_expected_energy_daily"]}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "B", JSON: []byte(`{"pvArray": "roof", "target": ["pv_expected_power", "pv_expected_energy_daily"]}`), TimeRange: timeRange, Interval: time.Hour},
+			{RefID: "C", JSON: []byte(`{"pvArray": "garage", "target": ["pv_expected_power"]}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	all, roof := resp.Responses["A"].Frames, resp.Responses["B"].Frames
+
+	// Dark at midnight, most of the rated 10 kWp at solar noon around 11:00 UTC
+	assert.Equal(t, 0.0, roof[0].Fields[1].At(0))
+	noon := roof[0].Fields[1].At(13).(float64)
+	assert.InDelta(t, 7.5, noon, 1)
+	assert.Greater(t, all[0].Fields[1].At(13), noon)
+
+	// One value per local day, a clear June day yields several kWh per kWp
+	energy := roof[1]
+	assert.Equal(t, 2, energy.Rows())
+	assert.Equal(t, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC).Add(-2*time.Hour), energy.Fields[0].At(0).(time.Time).UTC())
+	assert.InDelta(t, 65, energy.Fields[1].At(0), 10)
+	assert.Greater(t, all[1].Fields[1].At(0), energy.Fields[1].At(0))
+
+	assert.ErrorContains(t, resp.Responses["C"].Error, "unknown PV array: garage")
+
+	// Without arrays the metrics can't be computed
+	resp, err = (&plugin.Datasource{Latitude: 48.2, Longitude: 16.4}).QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, resp.Responses["A"].Error, "no PV arrays configured for pv_expected_power")
+}
+
+func TestCheckHealthPVArrays(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  48.2,
+		Longitude: 16.4,
+		PVArrays:  []models.PVArray{{Name: "roof", Kwp: 10, Tilt: 30}, {Name: "roof", Kwp: 5, Tilt: 10}},
+	}
+
+	resp, err := ds.CheckHealth(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, backend.HealthStatusError, resp.Status)
+	assert.Contains(t, resp.Message, "duplicate PV array: roof")
+
+	ds.PVArrays[1] = models.PVArray{Name: "carport", Kwp: 0}
+	resp, err = ds.CheckHealth(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Contains(t, resp.Message, "PV array carport: kWp must be above 0")
+}
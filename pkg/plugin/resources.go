@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// metricResource describes a metric for the frontend
+type metricResource struct {
+	Value    string   `json:"value"`
+	Title    string   `json:"title"`
+	Text     string   `json:"text"`
+	Unit     string   `json:"unit"`
+	Decimals int      `json:"decimals"`
+	Min      float64  `json:"min"`
+	States   []string `json:"states,omitempty"`
+}
+
+// annotationResource describes an annotation for the frontend
+type annotationResource struct {
+	Value  string   `json:"value"`
+	Title  string   `json:"title"`
+	Text   string   `json:"text"`
+	Tags   []string `json:"tags"`
+	Region bool     `json:"region"`
+}
+
+// CallResource serves the metric and annotation catalogue, so the frontend
+// doesn't keep its own copy of models.SunAndMoonMetrics and
+// models.SunAndMoonAnnotations, and the named locations of the datasource.
+func (d *Datasource) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendJSON(sender, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+
+	switch strings.Trim(req.Path, "/") {
+	case "metrics":
+		return sendJSON(sender, http.StatusOK, metricResources())
+	case "annotations":
+		return sendJSON(sender, http.StatusOK, annotationResources())
+	case "locations":
+		locations := d.Locations
+		if locations == nil {
+			locations = []models.Location{}
+		}
+		return sendJSON(sender, http.StatusOK, locations)
+	}
+
+	return sendJSON(sender, http.StatusNotFound, map[string]string{"error": "not found"})
+}
+
+// metricResources lists all metrics sorted by key
+func metricResources() []metricResource {
+	resources := []metricResource{}
+	for _, key := range sortedKeys(models.SunAndMoonMetrics) {
+		def := models.SunAndMoonMetrics[key]
+		resources = append(resources, metricResource{
+			Value:    key,
+			Title:    def.Title,
+			Text:     def.Text,
+			Unit:     def.Config.Unit,
+			Decimals: def.Config.Decimals,
+			Min:      def.Config.Min,
+			States:   def.Config.States,
+		})
+	}
+	return resources
+}
+
+// annotationResources lists all annotations sorted by key
+func annotationResources() []annotationResource {
+	resources := []annotationResource{}
+	for _, key := range sortedKeys(models.SunAndMoonAnnotations) {
+		def := models.SunAndMoonAnnotations[key]
+		resources = append(resources, annotationResource{
+			Value:  key,
+			Title:  def.Title,
+			Text:   def.Text,
+			Tags:   []string{def.Tag},
+			Region: def.IsRegion(),
+		})
+	}
+	return resources
+}
+
+// sortedKeys returns the keys of a catalogue map in a stable order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sendJSON sends body as JSON resource response
+func sendJSON(sender backend.CallResourceResponseSender, status int, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    payload,
+	})
+}
@@ -0,0 +1,79 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+// callResource calls the datasource resource handler and returns the response
+func callResource(t *testing.T, ds *plugin.Datasource, method string, path string) *backend.CallResourceResponse {
+	var resp *backend.CallResourceResponse
+	err := ds.CallResource(context.Background(), &backend.CallResourceRequest{
+		Method: method,
+		Path:   path,
+	}, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+		resp = r
+		return nil
+	}))
+	assert.NoError(t, err)
+	return resp
+}
+
+func TestCallResource(t *testing.T) {
+	ds := &plugin.Datasource{}
+
+	t.Run("should list all metrics", func(t *testing.T) {
+		resp := callResource(t, ds, http.MethodGet, "metrics")
+		assert.Equal(t, http.StatusOK, resp.Status)
+
+		var metrics []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(resp.Body, &metrics))
+		assert.Len(t, metrics, len(models.SunAndMoonMetrics))
+
+		for _, metric := range metrics {
+			def := models.SunAndMoonMetrics[metric["value"].(string)]
+			assert.Equal(t, def.Title, metric["title"])
+			assert.Equal(t, def.Config.Unit, metric["unit"])
+			assert.Equal(t, float64(def.Config.Decimals), metric["decimals"])
+			assert.Equal(t, def.Config.Min, metric["min"])
+		}
+	})
+
+	t.Run("should list all annotations", func(t *testing.T) {
+		resp := callResource(t, ds, http.MethodGet, "/annotations")
+		assert.Equal(t, http.StatusOK, resp.Status)
+
+		var annotations []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(resp.Body, &annotations))
+		assert.Len(t, annotations, len(models.SunAndMoonAnnotations))
+
+		for _, annotation := range annotations {
+			def := models.SunAndMoonAnnotations[annotation["value"].(string)]
+			assert.Equal(t, def.Title, annotation["title"])
+			assert.Equal(t, []interface{}{def.Tag}, annotation["tags"])
+			assert.Equal(t, def.IsRegion(), annotation["region"])
+		}
+	})
+
+	t.Run("should list the locations", func(t *testing.T) {
+		resp := callResource(t, ds, http.MethodGet, "locations")
+		assert.Equal(t, http.StatusOK, resp.Status)
+		assert.JSONEq(t, `[]`, string(resp.Body))
+
+		withLocations := &plugin.Datasource{Locations: []models.Location{{Name: "Hamburg", Latitude: 53.55, Longitude: 9.99, Timezone: "Europe/Berlin"}}}
+		resp = callResource(t, withLocations, http.MethodGet, "locations")
+		assert.JSONEq(t, `[{"name": "Hamburg", "latitude": 53.55, "longitude": 9.99, "elevation": null, "timezone": "Europe/Berlin"}]`, string(resp.Body))
+	})
+
+	t.Run("should reject unknown paths and methods", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, callResource(t, ds, http.MethodGet, "planets").Status)
+		assert.Equal(t, http.StatusMethodNotAllowed, callResource(t, ds, http.MethodPost, "metrics").Status)
+	})
+}
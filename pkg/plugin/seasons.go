@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// solarLongitudeEvents finds the instants at which the sun reaches the given
+// apparent longitude, north applies to observers on the northern hemisphere
+// and the equator, south to the southern hemisphere
+func solarLongitudeEvents(north float64, south float64) eventFinder {
+	return func(from time.Time, to time.Time, obs observer) []event {
+		longitude := north
+		if obs.Latitude < 0 {
+			longitude = south
+		}
+
+		events := []event{}
+		for year := from.UTC().Year(); year <= to.UTC().Year(); year++ {
+			deltaT := obs.spa(time.Date(year, 7, 1, 0, 0, 0, 0, time.UTC)).DeltaT
+			t := astro.SolarLongitudeTime(longitude, year, deltaT).In(from.Location())
+			if !t.Before(from) && t.Before(to) {
+				events = append(events, event{Time: t})
+			}
+		}
+		return events
+	}
+}
+
+// monthEvents finds the local midnights starting the given month, north and
+// south as in solarLongitudeEvents
+func monthEvents(north time.Month, south time.Month) eventFinder {
+	return func(from time.Time, to time.Time, obs observer) []event {
+		month := north
+		if obs.Latitude < 0 {
+			month = south
+		}
+
+		events := []event{}
+		for year := from.Year(); year <= to.Year(); year++ {
+			t := time.Date(year, month, 1, 0, 0, 0, 0, from.Location())
+			if !t.Before(from) && t.Before(to) {
+				events = append(events, event{Time: t})
+			}
+		}
+		return events
+	}
+}
@@ -0,0 +1,76 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataSeasons(t *testing.T) {
+	ds := &plugin.Datasource{}
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	targets := `"target": ["marchEquinox", "astronomicalSpring", "meteorologicalSpring"]`
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "Berlin", JSON: []byte(`{"latitude": "52.52", "longitude": "13.405", "timezone": "Europe/Berlin", ` + targets + `}`), TimeRange: timeRange},
+			{RefID: "Sydney", JSON: []byte(`{"latitude": "-33.87", "longitude": "151.21", "timezone": "Australia/Sydney", ` + targets + `}`), TimeRange: timeRange},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// One event per year, computed to the minute rather than on a fixed date
+	berlin := resp.Responses["Berlin"].Frames
+	for _, frame := range berlin {
+		assert.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "season", frame.Fields[3].At(0))
+	}
+	equinox := berlin[0].Fields[0].At(0).(time.Time)
+	assert.WithinDuration(t, time.Date(2024, 3, 20, 3, 6, 0, 0, time.UTC), equinox, time.Minute)
+	assert.Equal(t, equinox, berlin[1].Fields[0].At(0))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)).Unix(), berlin[2].Fields[0].At(0).(time.Time).Unix())
+
+	// Spring starts in September on the southern hemisphere
+	sydney := resp.Responses["Sydney"].Frames
+	assert.WithinDuration(t, equinox, sydney[0].Fields[0].At(0).(time.Time), 0)
+	assert.Equal(t, time.September, sydney[1].Fields[0].At(0).(time.Time).Month())
+	spring := sydney[2].Fields[0].At(0).(time.Time)
+	assert.Equal(t, "2024-09-01 00:00", spring.Format("2006-01-02 15:04"))
+}
+
+func TestQueryDataSeasonsFromAlert(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 52.52, Longitude: 13.405}
+
+	to := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	req := &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				JSON:      []byte(`{"target": ["septemberEquinox", "decemberSolstice"]}`),
+				TimeRange: backend.TimeRange{From: to.Add(-time.Minute), To: to},
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+	frames := resp.Responses["A"].Frames
+
+	// The September equinox was on the 22nd at 12:44 UTC
+	since := *frames[0].Fields[0].At(0).(*float64)
+	assert.InDelta(t, to.Sub(time.Date(2024, 9, 22, 12, 44, 0, 0, time.UTC)).Seconds(), since, 60)
+
+	// The latest December solstice was a year ago
+	since = *frames[1].Fields[0].At(0).(*float64)
+	assert.InDelta(t, to.Sub(time.Date(2023, 12, 22, 3, 27, 0, 0, time.UTC)).Seconds(), since, 60)
+}
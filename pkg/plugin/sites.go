@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/gazetteer"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// queryLocation is a named site of a query with several locations. A site
+// with only a name is the location of the datasource or the gazetteer city
+// with that name.
+type queryLocation struct {
+	Name      string `json:"name"`
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+	Timezone  string `json:"timezone"` // IANA timezone, the one of the query if empty
+}
+
+// coordinates parses the latitude and longitude of the location, or the
+// whole position in the latitude, see parseCoordinates
+func (l queryLocation) coordinates() (float64, float64, error) {
+	latitude, longitude, err := parseCoordinates(l.Latitude, l.Longitude)
+	var invalid coordinateError
+	if errors.As(err, &invalid) {
+		return 0, 0, fmt.Errorf("invalid %s of %s %q: %s", invalid.axis, l.Name, invalid.value, invalid.reason)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if latitude == nil {
+		return 0, 0, fmt.Errorf("invalid latitude of %s: empty", l.Name)
+	}
+	if longitude == nil {
+		return 0, 0, fmt.Errorf("invalid longitude of %s: empty", l.Name)
+	}
+	return *latitude, *longitude, nil
+}
+
+// site is a resolved location a query computes its frames for. The name is
+// empty for the single location of a query without locations.
+type site struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Elevation *float64 // Meters above sea level, the one of the query or datasource if not set
+	Location  *time.Location
+}
+
+// querySites resolves the locations of a query. Without a list of locations
+// the query has a single unnamed site at its own or the default location.
+func (d *Datasource) querySites(query backend.DataQuery, qm queryModel) ([]site, error) {
+	location, err := d.GetLocation(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if qm.Location != "" {
+		registered, err := d.location(qm.Location)
+		if err != nil {
+			return nil, err
+		}
+		// An explicit timezone of the query wins over the one of the location
+		s, err := registeredSite(registered, location)
+		if err != nil {
+			return nil, err
+		}
+		if qm.Timezone != "" {
+			s.Location = location
+		}
+		s.Name = ""
+		return []site{s}, nil
+	}
+
+	if len(qm.Locations) == 0 {
+		latitude, longitude, err := d.GetLatLon(query)
+		if err != nil {
+			return nil, err
+		}
+		return []site{{Latitude: latitude, Longitude: longitude, Location: location}}, nil
+	}
+
+	sites := []site{}
+	names := map[string]bool{}
+	for _, l := range qm.Locations {
+		if l.Name == "" {
+			return nil, fmt.Errorf("location without name")
+		}
+		if names[l.Name] {
+			return nil, fmt.Errorf("duplicate location: %s", l.Name)
+		}
+		names[l.Name] = true
+
+		if l.Latitude == "" && l.Longitude == "" {
+			registered, err := d.location(l.Name)
+			if err != nil {
+				return nil, err
+			}
+			s, err := registeredSite(registered, location)
+			if err != nil {
+				return nil, err
+			}
+			if l.Timezone != "" {
+				if s.Location, err = models.LoadLocation(l.Timezone); err != nil {
+					return nil, fmt.Errorf("invalid timezone of %s %q: %v", l.Name, l.Timezone, err)
+				}
+			}
+			sites = append(sites, s)
+			continue
+		}
+
+		latitude, longitude, err := l.coordinates()
+		if err != nil {
+			return nil, err
+		}
+
+		siteLocation := location
+		if l.Timezone != "" {
+			siteLocation, err = models.LoadLocation(l.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timezone of %s %q: %v", l.Name, l.Timezone, err)
+			}
+		}
+
+		sites = append(sites, site{Name: l.Name, Latitude: latitude, Longitude: longitude, Location: siteLocation})
+	}
+	return sites, nil
+}
+
+// location returns the location of the datasource with the given name, or
+// else the place of the embedded gazetteer like "Zurich" or "Berlin, DE"
+func (d *Datasource) location(name string) (models.Location, error) {
+	if location, ok := models.FindLocation(d.Locations, name); ok {
+		return location, nil
+	}
+	place, err := gazetteer.Lookup(name)
+	if errors.Is(err, gazetteer.ErrNotFound) {
+		return models.Location{}, fmt.Errorf("unknown location: %s, neither configured nor among the %d major cities of the offline list, give coordinates instead", name, gazetteer.Size())
+	}
+	if err != nil {
+		return models.Location{}, err
+	}
+	return models.Location{
+		Name:      name,
+		Latitude:  place.Latitude,
+		Longitude: place.Longitude,
+		Timezone:  place.Timezone,
+	}, nil
+}
+
+// registeredSite returns the site of a location of the datasource, in its
+// own timezone or the given one
+func registeredSite(l models.Location, location *time.Location) (site, error) {
+	if l.Timezone != "" {
+		var err error
+		if location, err = models.LoadLocation(l.Timezone); err != nil {
+			return site{}, fmt.Errorf("invalid timezone of %s %q: %v", l.Name, l.Timezone, err)
+		}
+	}
+	return site{Name: l.Name, Latitude: l.Latitude, Longitude: l.Longitude, Elevation: l.Elevation, Location: location}, nil
+}
+
+// labels returns the labels of the series of a target at the site
+func (s site) labels(target string) data.Labels {
+	labels := data.Labels{"target": target}
+	if s.Name != "" {
+		labels["site"] = s.Name
+	}
+	return labels
+}
+
+// displayName returns the series name of a metric or annotation at the site
+func (s site) displayName(title string) string {
+	if s.Name == "" {
+		return title
+	}
+	return fmt.Sprintf("%s (%s)", title, s.Name)
+}
+
+// labelFields labels the fields of an annotation frame other than the times
+// with the site, annotation frames of the single location stay unlabelled
+func (s site) labelFields(frame *data.Frame) *data.Frame {
+	if s.Name == "" {
+		return frame
+	}
+	for _, field := range frame.Fields {
+		if field.Type().Time() {
+			continue
+		}
+		field.Labels = data.Labels{"site": s.Name}
+	}
+	return frame
+}
@@ -0,0 +1,181 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDataMultipleLocations(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Timezone: "Europe/Berlin"}
+
+	day := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+	}
+	sites := `"locations": [
+		{"name": "Hamburg", "latitude": "53.55", "longitude": "9.99"},
+		{"name": "Munich", "latitude": "48.14", "longitude": "11.58"},
+		{"name": "Lisbon", "latitude": "38.72", "longitude": "-9.14", "timezone": "Europe/Lisbon"}
+	]`
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{` + sites + `, "target": ["sun_altitude", "sunrise"]}`), TimeRange: day, Interval: time.Hour},
+			{RefID: "B", JSON: []byte(`{"locations": [{"name": "Hamburg", "latitude": "53.55", "longitude": "9.99"}, {"name": "Hamburg", "latitude": "53.55", "longitude": "9.99"}], "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "C", JSON: []byte(`{"locations": [{"name": "Hamburg", "latitude": "north", "longitude": "9.99"}], "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "D", JSON: []byte(`{"locations": [{"latitude": "53.55", "longitude": "9.99"}], "target": ["sunrise"]}`), TimeRange: day},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// One frame per location and target, in the order of the locations
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 6)
+	assert.Equal(t, data.Labels{"target": "sun_altitude", "site": "Hamburg"}, frames[0].Fields[1].Labels)
+	assert.Equal(t, "Sun altitude (Hamburg)", frames[0].Fields[1].Config.DisplayNameFromDS)
+	assert.Equal(t, data.Labels{"site": "Munich"}, frames[3].Fields[1].Labels)
+	assert.Equal(t, "Sunrise (Lisbon)", frames[5].Name)
+
+	// Sunrise comes earlier in the north at midsummer, Lisbon uses its own
+	// timezone and its days start an hour later
+	hamburg := frames[1].Fields[0].At(0).(time.Time)
+	munich := frames[3].Fields[0].At(0).(time.Time)
+	lisbon := frames[5].Fields[0].At(frames[5].Rows() - 1).(time.Time)
+	assert.Equal(t, "Europe/Berlin", hamburg.Location().String())
+	assert.Equal(t, "Europe/Lisbon", lisbon.Location().String())
+	assert.True(t, lisbon.After(hamburg))
+	assert.True(t, hamburg.Before(munich))
+
+	assert.ErrorContains(t, resp.Responses["B"].Error, "duplicate location: Hamburg")
+	assert.ErrorContains(t, resp.Responses["C"].Error, "invalid latitude of Hamburg")
+	assert.ErrorContains(t, resp.Responses["D"].Error, "location without name")
+}
+
+func TestQueryDataMultipleLocationsAlerting(t *testing.T) {
+	ds := &plugin.Datasource{}
+
+	req := &backend.QueryDataRequest{
+		Headers: map[string]string{"FromAlert": "true"},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"locations": [{"name": "North", "latitude": "60", "longitude": "0"}, {"name": "South", "latitude": "-60", "longitude": "0"}], "target": ["day_length"]}`), TimeRange: backend.TimeRange{
+				From: time.Date(2024, 6, 21, 11, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Alert rules get one labelled number per site
+	frames := resp.Responses["A"].Frames
+	assert.Len(t, frames, 2)
+	assert.Equal(t, "South", frames[1].Fields[0].Labels["site"])
+	assert.Greater(t, *frames[0].Fields[0].At(0).(*float64), *frames[1].Fields[0].At(0).(*float64))
+}
+
+func TestQueryDataRegisteredLocations(t *testing.T) {
+	elevation := 1000.0
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Locations: []models.Location{
+		{Name: "Hamburg", Latitude: 53.55, Longitude: 9.99, Timezone: "Europe/Berlin"},
+		{Name: "Hamburg high", Latitude: 53.55, Longitude: 9.99, Elevation: &elevation},
+	}}
+
+	day := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"location": "Hamburg", "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "B", JSON: []byte(`{"latitude": "53.55", "longitude": "9.99", "timezone": "Europe/Berlin", "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "C", JSON: []byte(`{"locations": [{"name": "Hamburg"}, {"name": "Hamburg high"}], "timezone": "Europe/Berlin", "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "D", JSON: []byte(`{"location": "Atlantis", "target": ["sunrise"]}`), TimeRange: day},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The location replaces coordinates and timezone of the query
+	byName := resp.Responses["A"].Frames[0]
+	assert.Equal(t, "Sunrise", byName.Name)
+	assert.Equal(t, resp.Responses["B"].Frames[0].Fields[0].At(0), byName.Fields[0].At(0))
+
+	// Sites of the registry are labelled, the elevation lowers the horizon
+	frames := resp.Responses["C"].Frames
+	assert.Equal(t, "Hamburg high", frames[1].Fields[1].Labels["site"])
+	assert.True(t, frames[1].Fields[0].At(0).(time.Time).Before(frames[0].Fields[0].At(0).(time.Time).Add(-5*time.Minute)))
+
+	assert.ErrorContains(t, resp.Responses["D"].Error, "unknown location: Atlantis, neither configured nor among the")
+}
+
+func TestQueryDataGazetteerLocations(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 48.2, Longitude: 16.4, Locations: []models.Location{
+		{Name: "Zurich", Latitude: 47.5, Longitude: 8.5},
+	}}
+
+	day := backend.TimeRange{
+		From: time.Date(2024, 6, 20, 22, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC),
+	}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"location": "Berlin, DE", "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "B", JSON: []byte(`{"latitude": "52.52437", "longitude": "13.41053", "timezone": "Europe/Berlin", "target": ["sunrise"]}`), TimeRange: day},
+			{RefID: "C", JSON: []byte(`{"locations": [{"name": "Zurich"}, {"name": "Tromsø"}], "target": ["sun_altitude"]}`), TimeRange: day},
+			{RefID: "D", JSON: []byte(`{"location": "Berlin, Germany", "target": ["sunrise"]}`), TimeRange: day},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Coordinates and timezone come from the gazetteer
+	berlin := resp.Responses["A"].Frames[0].Fields[0].At(0).(time.Time)
+	assert.Equal(t, resp.Responses["B"].Frames[0].Fields[0].At(0), berlin)
+	assert.Equal(t, "Europe/Berlin", berlin.Location().String())
+
+	// Sites mix the registry and the gazetteer, Tromsø in its midnight sun
+	frames := resp.Responses["C"].Frames
+	assert.Equal(t, "Zurich", frames[0].Fields[1].Labels["site"])
+	assert.Equal(t, "Tromsø", frames[1].Fields[1].Labels["site"])
+	for i := 0; i < frames[1].Rows(); i++ {
+		assert.Greater(t, frames[1].Fields[1].At(i).(float64), 0.0)
+	}
+
+	assert.ErrorContains(t, resp.Responses["D"].Error, "invalid country code")
+}
+
+func TestNewDatasourceLocations(t *testing.T) {
+	instance, err := plugin.NewDatasource(context.Background(), backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"locations": [{"name": "Hamburg", "latitude": 53.55, "longitude": 9.99, "elevation": 6, "timezone": "Europe/Berlin"}]}`),
+	})
+	assert.NoError(t, err)
+	ds := instance.(*plugin.Datasource)
+	assert.Equal(t, "Hamburg", ds.Locations[0].Name)
+	assert.Equal(t, 6.0, *ds.Locations[0].Elevation)
+
+	_, err = plugin.NewDatasource(context.Background(), backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"locations": [{"name": "Hamburg", "latitude": 153.55, "longitude": 9.99}]}`),
+	})
+	assert.ErrorContains(t, err, "location Hamburg: latitude not in range -90 to +90")
+
+	_, err = plugin.NewDatasource(context.Background(), backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"locations": [{"name": "Hamburg", "latitude": 53.55, "longitude": 9.99, "timezone": "Europe/Hamburg"}]}`),
+	})
+	assert.ErrorContains(t, err, "location Hamburg: unknown timezone: Europe/Hamburg")
+
+	_, err = models.LoadPluginSettings(backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"locations": [{"name": "Hamburg", "latitude": 53.55, "longitude": 9.99}, {"name": "Hamburg", "latitude": 53.55, "longitude": 9.99}]}`),
+	})
+	assert.ErrorContains(t, err, "duplicate location: Hamburg")
+}
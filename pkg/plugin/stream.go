@@ -0,0 +1,225 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// Metrics pushed on position streams, one field each
+var streamMetrics = []string{"sun_altitude", "sun_azimuth", "moon_altitude", "moon_azimuth", "moon_illumination"}
+
+// Push interval if the channel path doesn't set one
+const defaultStreamInterval = 10 * time.Second
+
+// streamPath holds the parsed parts of a position channel path
+type streamPath struct {
+	Latitude  float64
+	Longitude float64
+	Interval  time.Duration
+
+	// Settings of the query that differ from the datasource, nil or empty
+	// for the ones of the datasource
+	Elevation   *float64
+	Pressure    *float64
+	Temperature *float64
+	Engine      string
+	DeltaT      *float64
+}
+
+// parseStreamPath parses channel paths like position/<lat>/<lon> with an
+// optional trailing push interval in seconds, followed by the observer
+// settings of the query as key=value segments, e.g.
+// position/52.52/13.405/5/pressure=990/engine=spa.
+func parseStreamPath(path string) (streamPath, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "position" || len(parts) < 3 {
+		return streamPath{}, fmt.Errorf("unknown channel path: %s", path)
+	}
+
+	latitude, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return streamPath{}, fmt.Errorf("invalid latitude: %s", parts[1])
+	}
+	longitude, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || longitude < -360 || longitude > 360 {
+		return streamPath{}, fmt.Errorf("invalid longitude: %s", parts[2])
+	}
+	sp := streamPath{Latitude: latitude, Longitude: longitude, Interval: defaultStreamInterval}
+
+	options := parts[3:]
+	if len(options) > 0 && !strings.Contains(options[0], "=") {
+		seconds, err := strconv.Atoi(options[0])
+		if err != nil || seconds < 1 {
+			return streamPath{}, fmt.Errorf("invalid interval: %s", options[0])
+		}
+		sp.Interval = time.Duration(seconds) * time.Second
+		options = options[1:]
+	}
+
+	for _, option := range options {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return streamPath{}, fmt.Errorf("invalid channel option: %s", option)
+		}
+		if key == "engine" {
+			sp.Engine = value
+			continue
+		}
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return streamPath{}, fmt.Errorf("invalid %s: %s", key, value)
+		}
+		switch key {
+		case "elevation":
+			sp.Elevation = &number
+		case "pressure":
+			sp.Pressure = &number
+		case "temperature":
+			sp.Temperature = &number
+		case "deltaT":
+			sp.DeltaT = &number
+		default:
+			return streamPath{}, fmt.Errorf("unknown channel option: %s", key)
+		}
+	}
+
+	return sp, nil
+}
+
+// streamObserver returns the observer of a channel, the one of the datasource
+// with the settings of the query in the path
+func (d *Datasource) streamObserver(path streamPath) (observer, error) {
+	obs := d.observer(path.Latitude, path.Longitude)
+	if path.Elevation != nil {
+		obs.Elevation = *path.Elevation
+	}
+	if path.Pressure != nil {
+		obs.Pressure = path.Pressure
+	}
+	if path.Temperature != nil {
+		obs.Temperature = path.Temperature
+	}
+	obs.Engine = path.Engine
+	obs.DeltaT = path.DeltaT
+	return obs, obs.validate()
+}
+
+// streamChannel returns the channel a query with the observer subscribes to
+// for live updates. Settings of the observer that differ from the datasource
+// become part of the path, so every update is computed like the first frame.
+func (d *Datasource) streamChannel(uid string, obs observer, interval int) string {
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	path := fmt.Sprintf("position/%s/%s", formatFloat(obs.Latitude), formatFloat(obs.Longitude))
+	if interval > 0 {
+		path += "/" + strconv.Itoa(interval)
+	}
+
+	defaults := d.observer(obs.Latitude, obs.Longitude)
+	if obs.Elevation != defaults.Elevation {
+		path += "/elevation=" + formatFloat(obs.Elevation)
+	}
+	if obs.pressure() != defaults.pressure() {
+		path += "/pressure=" + formatFloat(obs.pressure())
+	}
+	if obs.temperature() != defaults.temperature() {
+		path += "/temperature=" + formatFloat(obs.temperature())
+	}
+	if obs.Engine != "" && obs.Engine != engineSuncalc {
+		path += "/engine=" + obs.Engine
+	}
+	if obs.DeltaT != nil {
+		path += "/deltaT=" + formatFloat(*obs.DeltaT)
+	}
+
+	return live.Channel{Scope: live.ScopeDatasource, Namespace: uid, Path: path}.String()
+}
+
+// positionFrame computes the current sun and moon position as a single row
+func positionFrame(t time.Time, obs observer) *data.Frame {
+	frame := data.NewFrame("Position", data.NewField("Time", nil, []time.Time{t}))
+
+	for _, metric := range streamMetrics {
+		def := models.SunAndMoonMetrics[metric]
+		frame.Fields = append(frame.Fields,
+			data.NewField(metric, nil, []float64{metricValue(metric, t, obs)}).SetConfig(&data.FieldConfig{
+				DisplayNameFromDS: def.Title,
+				Unit:              def.Config.Unit,
+				Decimals:          uint16Ptr(uint16(def.Config.Decimals)),
+			}),
+		)
+	}
+
+	return frame
+}
+
+// SubscribeStream accepts subscriptions to valid position channels and
+// sends the current position right away.
+func (d *Datasource) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	path, err := parseStreamPath(req.Path)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, nil
+	}
+	obs, err := d.streamObserver(path)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, nil
+	}
+
+	initialData, err := backend.NewInitialFrame(positionFrame(time.Now(), obs), data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backend.SubscribeStreamResponse{
+		Status:      backend.SubscribeStreamStatusOK,
+		InitialData: initialData,
+	}, nil
+}
+
+// RunStream pushes the sun and moon position until all subscribers left
+func (d *Datasource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	path, err := parseStreamPath(req.Path)
+	if err != nil {
+		return err
+	}
+	obs, err := d.streamObserver(path)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(path.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-ticker.C:
+			if err := sender.SendFrame(positionFrame(t, obs), data.IncludeAll); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PublishStream rejects publications, positions are computed, not received
+func (d *Datasource) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{
+		Status: backend.PublishStreamStatusPermissionDenied,
+	}, nil
+}
@@ -0,0 +1,159 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+// packetRecorder collects the packets sent on a stream
+type packetRecorder struct {
+	packets []*backend.StreamPacket
+}
+
+func (r *packetRecorder) Send(packet *backend.StreamPacket) error {
+	r.packets = append(r.packets, packet)
+	return nil
+}
+
+func TestSubscribeStream(t *testing.T) {
+	ds := &plugin.Datasource{}
+
+	for path, status := range map[string]backend.SubscribeStreamStatus{
+		"position/52.52/13.405":                              backend.SubscribeStreamStatusOK,
+		"position/-33.9/18.4/5":                              backend.SubscribeStreamStatusOK,
+		"position/95/13.405":                                 backend.SubscribeStreamStatusNotFound,
+		"position/52.52":                                     backend.SubscribeStreamStatusNotFound,
+		"position/52.52/13.405/0":                            backend.SubscribeStreamStatusNotFound,
+		"velocity/52.52/13.405/10":                           backend.SubscribeStreamStatusNotFound,
+		"position/52.52/13.405/5/pressure=990/elevation=300": backend.SubscribeStreamStatusOK,
+		"position/52.52/13.405/pressure=990":                 backend.SubscribeStreamStatusOK,
+		"position/52.52/13.405/pressure=-5":                  backend.SubscribeStreamStatusNotFound,
+		"position/52.52/13.405/humidity=80":                  backend.SubscribeStreamStatusNotFound,
+		"position/52.52/13.405/engine=spa/deltaT=69.2":       backend.SubscribeStreamStatusOK,
+		"position/52.52/13.405/engine=vsop":                  backend.SubscribeStreamStatusNotFound,
+	} {
+		resp, err := ds.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{Path: path})
+		assert.NoError(t, err)
+		assert.Equal(t, status, resp.Status, path)
+		if status == backend.SubscribeStreamStatusOK {
+			assert.NotNil(t, resp.InitialData, path)
+		}
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	ds := &plugin.Datasource{}
+	recorder := &packetRecorder{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	err := ds.RunStream(ctx, &backend.RunStreamRequest{Path: "position/52.52/13.405/1"}, backend.NewStreamSender(recorder))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, recorder.packets)
+
+	var frame data.Frame
+	assert.NoError(t, json.Unmarshal(recorder.packets[0].Data, &frame))
+	assert.Equal(t, "Position", frame.Name)
+	assert.Len(t, frame.Fields, 6)
+	assert.Equal(t, "sun_altitude", frame.Fields[1].Name)
+}
+
+func TestQueryDataLive(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "sunandmoon"},
+		},
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"live": true, "liveInterval": 5, "target": ["sun_altitude"]}`),
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	frame := resp.Responses["A"].Frames[0]
+	assert.Equal(t, "ds/sunandmoon/position/52.52/13.405/5", frame.Meta.Channel)
+	assert.Equal(t, 1, frame.Rows())
+}
+
+func TestRunStreamObserverSettings(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 52.52, Longitude: 13.405}
+	settings := `"elevation": "3000", "pressure": "700", "temperature": "-10"`
+
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "sunandmoon"},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"live": true, "liveInterval": 1, ` + settings + `, "target": ["sun_altitude"]}`)},
+		},
+	}
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The settings of the query become part of the channel
+	initial := resp.Responses["A"].Frames[0]
+	channel, err := live.ParseChannel(initial.Meta.Channel)
+	assert.NoError(t, err)
+	assert.Equal(t, "position/52.52/13.405/1/elevation=3000/pressure=700/temperature=-10", channel.Path)
+
+	recorder := &packetRecorder{}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, ds.RunStream(ctx, &backend.RunStreamRequest{Path: channel.Path}, backend.NewStreamSender(recorder)))
+	assert.NotEmpty(t, recorder.packets)
+
+	var pushed data.Frame
+	assert.NoError(t, json.Unmarshal(recorder.packets[0].Data, &pushed))
+
+	// The initial and the pushed frame match a regular query with the same
+	// settings at their instant
+	for _, frame := range []*data.Frame{initial, &pushed} {
+		at := frame.Fields[0].At(0).(time.Time)
+		query := backend.DataQuery{
+			RefID:     "B",
+			JSON:      []byte(`{` + settings + `, "target": ["sun_altitude"]}`),
+			TimeRange: backend.TimeRange{From: at, To: at.Add(time.Second)},
+			Interval:  time.Second,
+		}
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{Queries: []backend.DataQuery{query}})
+		assert.NoError(t, err)
+		expected := resp.Responses["B"].Frames[0].Fields[1].At(0).(float64)
+		assert.InDelta(t, expected, frame.Fields[1].At(0).(float64), 1e-4)
+	}
+}
+
+func TestQueryDataLiveEngine(t *testing.T) {
+	ds := &plugin.Datasource{Latitude: 52.52, Longitude: 13.405}
+
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "sunandmoon"},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"live": true, "engine": "spa", "deltaT": 69.2, "target": ["sun_altitude"]}`)},
+		},
+	}
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Updates on the channel use the engine of the first frame
+	assert.Equal(t, "ds/sunandmoon/position/52.52/13.405/engine=spa/deltaT=69.2", resp.Responses["A"].Frames[0].Meta.Channel)
+}
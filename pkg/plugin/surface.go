@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/simonbuehler/sunandmoon_backend/pkg/astro"
+)
+
+// Geometries of a surface
+const (
+	trackerFixed      = "fixed"
+	trackerSingleAxis = "singleAxis"
+)
+
+// Defaults of a single-axis tracker
+const (
+	defaultMaxAngle            = 60.0 // Rotation limit in degrees
+	defaultGroundCoverageRatio = 0.35 // Module width over row spacing
+)
+
+// Share of the global irradiance reflected by grass and soil
+const groundAlbedo = 0.2
+
+// surface is a tilted plane like a PV module, or the axis of a single-axis
+// tracker carrying the modules
+type surface struct {
+	Tilt    float64  // Degrees from horizontal
+	Azimuth *float64 // Degrees clockwise from north, facing the equator if not set
+	Tracker string   // Empty or "fixed" for a fixed plane, "singleAxis" for a tracker
+
+	MaxAngle            *float64 // Rotation limit of the tracker in degrees
+	GroundCoverageRatio *float64 // Module width over row spacing for backtracking, 0 disables it
+}
+
+// validate checks the orientation and the tracker settings
+func (s surface) validate() error {
+	switch s.Tracker {
+	case "", trackerFixed, trackerSingleAxis:
+	default:
+		return fmt.Errorf("unknown tracker: %s", s.Tracker)
+	}
+
+	if s.Tilt < 0 || s.Tilt > 90 {
+		return fmt.Errorf("surface tilt not in range 0 to 90: %g", s.Tilt)
+	}
+	if s.Azimuth != nil && (*s.Azimuth < 0 || *s.Azimuth > 360) {
+		return fmt.Errorf("surface azimuth not in range 0 to 360: %g", *s.Azimuth)
+	}
+	if s.MaxAngle != nil && (*s.MaxAngle < 0 || *s.MaxAngle > 90) {
+		return fmt.Errorf("tracker max angle not in range 0 to 90: %g", *s.MaxAngle)
+	}
+	if s.GroundCoverageRatio != nil && (*s.GroundCoverageRatio < 0 || *s.GroundCoverageRatio >= 1) {
+		return fmt.Errorf("ground coverage ratio not in range 0 to 1: %g", *s.GroundCoverageRatio)
+	}
+	return nil
+}
+
+// azimuth returns the direction the surface or the tracker axis points to,
+// south in the northern hemisphere and north in the southern by default
+func (s surface) azimuth(latitude float64) float64 {
+	if s.Azimuth != nil {
+		return *s.Azimuth
+	}
+	if latitude < 0 {
+		return 0
+	}
+	return 180
+}
+
+// orientation returns the tracker rotation, tilt and azimuth of the
+// modules for the sun at the given apparent zenith and azimuth. Fixed
+// surfaces don't rotate.
+func (s surface) orientation(zenith float64, azimuth float64, latitude float64) (float64, float64, float64) {
+	if s.Tracker != trackerSingleAxis {
+		return 0, s.Tilt, s.azimuth(latitude)
+	}
+
+	maxAngle := defaultMaxAngle
+	if s.MaxAngle != nil {
+		maxAngle = *s.MaxAngle
+	}
+	gcr := defaultGroundCoverageRatio
+	if s.GroundCoverageRatio != nil {
+		gcr = *s.GroundCoverageRatio
+	}
+	return astro.SingleAxisTracker(zenith, azimuth, s.Tilt, s.azimuth(latitude), maxAngle, gcr)
+}
+
+// planeOfArray returns the clear-sky irradiance on the surface at time t and
+// the angle of incidence in degrees
+func planeOfArray(t time.Time, obs observer, s surface) (astro.PlaneOfArray, float64) {
+	altitude, azimuth := sunPosition(t, obs)
+	zenith := 90 - obs.refract(altitude)
+
+	_, tilt, surfaceAzimuth := s.orientation(zenith, azimuth, obs.Latitude)
+	aoi := astro.AngleOfIncidence(zenith, azimuth, tilt, surfaceAzimuth)
+
+	poa := astro.PlaneOfArrayHayDavies(clearSky(t, obs), zenith, aoi, tilt, astro.ExtraterrestrialIrradiance(t), groundAlbedo)
+	return poa, aoi
+}
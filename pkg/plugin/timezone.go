@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+)
+
+// GetLocation resolves the timezone used for local clock times and day
+// boundaries. The query timezone wins over the datasource setting, which wins
+// over the dashboard timezone sent by the frontend. Falls back to UTC.
+func (d *Datasource) GetLocation(query backend.DataQuery) (*time.Location, error) {
+	var qm queryModel
+	err := json.Unmarshal(query.JSON, &qm)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling query JSON: %v", err)
+	}
+
+	for _, name := range []string{qm.Timezone, d.Timezone, qm.DashboardTimezone} {
+		// "browser" is resolved by the frontend, the backend can't know it
+		if name == "" || name == "browser" {
+			continue
+		}
+		location, err := models.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %v", name, err)
+		}
+		return location, nil
+	}
+
+	return time.UTC, nil
+}
+
+// localDays returns the local midnight of every calendar day in location
+// that overlaps the time range.
+func localDays(timeRange backend.TimeRange, location *time.Location) []time.Time {
+	days := []time.Time{}
+
+	from := timeRange.From.In(location)
+	// AddDate keeps the wall clock at midnight across DST changes
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, location); day.Before(timeRange.To); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
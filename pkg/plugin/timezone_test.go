@@ -0,0 +1,108 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/models"
+	"github.com/simonbuehler/sunandmoon_backend/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLocation(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+
+	t.Run("should fall back to UTC", func(t *testing.T) {
+		location, err := ds.GetLocation(backend.DataQuery{JSON: []byte(`{}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, time.UTC, location)
+	})
+
+	t.Run("should use the dashboard timezone", func(t *testing.T) {
+		location, err := ds.GetLocation(backend.DataQuery{JSON: []byte(`{"dashboardTimezone": "Europe/Berlin"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", location.String())
+	})
+
+	t.Run("should ignore the browser dashboard timezone", func(t *testing.T) {
+		location, err := ds.GetLocation(backend.DataQuery{JSON: []byte(`{"dashboardTimezone": "browser"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, time.UTC, location)
+	})
+
+	t.Run("should prefer the query timezone", func(t *testing.T) {
+		withSettings := &plugin.Datasource{Timezone: "America/New_York"}
+		location, err := withSettings.GetLocation(backend.DataQuery{JSON: []byte(`{"timezone": "Asia/Tokyo", "dashboardTimezone": "Europe/Berlin"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, "Asia/Tokyo", location.String())
+
+		location, err = withSettings.GetLocation(backend.DataQuery{JSON: []byte(`{"dashboardTimezone": "Europe/Berlin"}`)})
+		assert.NoError(t, err)
+		assert.Equal(t, "America/New_York", location.String())
+	})
+
+	t.Run("should return an error for an unknown timezone", func(t *testing.T) {
+		_, err := ds.GetLocation(backend.DataQuery{JSON: []byte(`{"timezone": "Mars/Olympus"}`)})
+		assert.Error(t, err)
+	})
+}
+
+func TestQueryDataNoonInTimezone(t *testing.T) {
+	ds := &plugin.Datasource{
+		Latitude:  52.52,
+		Longitude: 13.405,
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID: "A",
+				JSON:  []byte(`{"timezone": "Europe/Berlin", "target": ["noon", "midnight"]}`),
+				TimeRange: backend.TimeRange{
+					// Spans the switch to daylight saving time on 2024-03-31
+					From: time.Date(2024, 3, 29, 22, 30, 0, 0, time.UTC),
+					To:   time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	resp, err := ds.QueryData(context.Background(), req)
+	assert.NoError(t, err)
+
+	// Local days from March 29 (23:30 in Berlin) to April 2 (02:00 in Berlin)
+	days := []int{29, 30, 31, 1, 2}
+	noon := resp.Responses["A"].Frames[0]
+	midnight := resp.Responses["A"].Frames[1]
+	assert.Equal(t, len(days), noon.Rows())
+	assert.Equal(t, len(days), midnight.Rows())
+
+	for i, day := range days {
+		n := noon.Fields[0].At(i).(time.Time).In(berlin)
+		m := midnight.Fields[0].At(i).(time.Time).In(berlin)
+		assert.Equal(t, day, n.Day())
+		assert.Equal(t, 12, n.Hour())
+		assert.Equal(t, day, m.Day())
+		assert.Equal(t, 0, m.Hour())
+	}
+}
+
+func TestLoadPluginSettingsTimezone(t *testing.T) {
+	// Settings accept Grafana's lowercase "utc" like queries and locations do
+	settings, err := models.LoadPluginSettings(backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"timezone": "utc", "locations": [{"name": "Greenwich", "latitude": 51.48, "longitude": 0, "timezone": "utc"}]}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "utc", *settings.Timezone)
+
+	_, err = models.LoadPluginSettings(backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"timezone": "Mars/Olympus"}`),
+	})
+	assert.ErrorContains(t, err, "Unknown timezone: Mars/Olympus")
+}
@@ -1,7 +1,13 @@
 import React, { ChangeEvent, PureComponent } from 'react';
-import { Alert, InlineField, Input } from '@grafana/ui';
-import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
-import { SunAndMoonDataSourceOptions } from '../types';
+import { Alert, Button, InlineField, Input, Select } from '@grafana/ui';
+import { DataSourcePluginOptionsEditorProps, SelectableValue } from '@grafana/data';
+import { Location, PVArray, SunAndMoonDataSourceOptions } from '../types';
+
+// Geometrien eines PV-Modulfelds
+const trackers: Array<SelectableValue<PVArray['tracker']>> = [
+  { label: 'Fixed', value: 'fixed' },
+  { label: 'Single-axis tracker', value: 'singleAxis' },
+];
 
 export interface Props extends DataSourcePluginOptionsEditorProps<SunAndMoonDataSourceOptions> { }
 
@@ -50,6 +56,102 @@ export class ConfigEditor extends PureComponent<Props> {
     onOptionsChange({ ...options, jsonData });
   };
 
+  onTimezoneChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      timezone: event.target.value || undefined,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  onNumberChange = (key: 'elevation' | 'pressure' | 'temperature' | 'linkeTurbidity') => (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      [key]: isNaN(value) ? undefined : value,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  setPVArrays = (pvArrays: PVArray[]) => {
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      pvArrays,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  updatePVArray = (index: number, changes: Partial<PVArray>) => {
+    const pvArrays = [...(this.props.options.jsonData.pvArrays || [])];
+    pvArrays[index] = { ...pvArrays[index], ...changes };
+    this.setPVArrays(pvArrays);
+  };
+
+  onPVArrayNameChange = (index: number) => (event: ChangeEvent<HTMLInputElement>) => {
+    this.updatePVArray(index, { name: event.target.value });
+  };
+
+  onPVArrayNumberChange =
+    (index: number, key: 'kwp' | 'tilt' | 'azimuth' | 'temperatureCoefficient' | 'losses') =>
+    (event: ChangeEvent<HTMLInputElement>) => {
+      const value = parseFloat(event.target.value);
+      this.updatePVArray(index, { [key]: isNaN(value) ? undefined : value });
+    };
+
+  onPVArrayTrackerChange = (index: number) => (selected: SelectableValue<PVArray['tracker']>) => {
+    this.updatePVArray(index, { tracker: selected.value });
+  };
+
+  onAddPVArray = () => {
+    const pvArrays = this.props.options.jsonData.pvArrays || [];
+    this.setPVArrays([...pvArrays, { name: `Array ${pvArrays.length + 1}`, kwp: 1, tilt: 30 }]);
+  };
+
+  onRemovePVArray = (index: number) => () => {
+    this.setPVArrays((this.props.options.jsonData.pvArrays || []).filter((_, i) => i !== index));
+  };
+
+  setLocations = (locations: Location[]) => {
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      locations,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  updateLocation = (index: number, changes: Partial<Location>) => {
+    const locations = [...(this.props.options.jsonData.locations || [])];
+    locations[index] = { ...locations[index], ...changes };
+    this.setLocations(locations);
+  };
+
+  onLocationTextChange = (index: number, key: 'name' | 'timezone') => (event: ChangeEvent<HTMLInputElement>) => {
+    this.updateLocation(index, { [key]: event.target.value || undefined });
+  };
+
+  onLocationNumberChange =
+    (index: number, key: 'latitude' | 'longitude' | 'elevation') => (event: ChangeEvent<HTMLInputElement>) => {
+      const value = parseFloat(event.target.value);
+      this.updateLocation(index, { [key]: isNaN(value) ? undefined : value });
+    };
+
+  onAddLocation = () => {
+    const { jsonData } = this.props.options;
+    const locations = jsonData.locations || [];
+    this.setLocations([
+      ...locations,
+      { name: `Site ${locations.length + 1}`, latitude: jsonData.latitude || 0, longitude: jsonData.longitude || 0 },
+    ]);
+  };
+
+  onRemoveLocation = (index: number) => () => {
+    this.setLocations((this.props.options.jsonData.locations || []).filter((_, i) => i !== index));
+  };
+
   render() {
     const { options } = this.props;
     const { jsonData } = options;
@@ -94,6 +196,220 @@ export class ConfigEditor extends PureComponent<Props> {
             />
           </InlineField>
         </div>
+        <div className="gf-form">
+          <InlineField label="Timezone" labelWidth={14} tooltip="IANA timezone for noon, midnight and day boundaries">
+            <Input
+              className="timezone"
+              aria-label="Timezone"
+              onChange={this.onTimezoneChange}
+              value={jsonData.timezone || ''}
+              placeholder="Dashboard timezone"
+              width={32}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form">
+          <InlineField label="Elevation" labelWidth={14} tooltip="Meters above sea level, lowers the horizon for rise and set times">
+            <Input
+              className="elevation"
+              aria-label="Elevation"
+              onChange={this.onNumberChange('elevation')}
+              value={jsonData.elevation ?? ''}
+              placeholder="0"
+              type="number"
+              width={32}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form">
+          <InlineField label="Pressure" labelWidth={14} tooltip="Air pressure in mbar for the refraction correction">
+            <Input
+              className="pressure"
+              aria-label="Pressure"
+              onChange={this.onNumberChange('pressure')}
+              value={jsonData.pressure ?? ''}
+              placeholder="1013.25"
+              type="number"
+              min={0}
+              width={32}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form">
+          <InlineField label="Temperature" labelWidth={14} tooltip="Air temperature in °C for the refraction correction">
+            <Input
+              className="temperature"
+              aria-label="Temperature"
+              onChange={this.onNumberChange('temperature')}
+              value={jsonData.temperature ?? ''}
+              placeholder="15"
+              type="number"
+              width={32}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form">
+          <InlineField
+            label="Turbidity"
+            labelWidth={14}
+            tooltip="Linke turbidity for the clear-sky irradiance, 2 for very clear and 6 for hazy air"
+          >
+            <Input
+              className="linkeTurbidity"
+              aria-label="Linke turbidity"
+              onChange={this.onNumberChange('linkeTurbidity')}
+              value={jsonData.linkeTurbidity ?? ''}
+              placeholder="3"
+              type="number"
+              min={1}
+              max={10}
+              width={32}
+            />
+          </InlineField>
+        </div>
+        <h3 className="page-heading">PV arrays</h3>
+        {(jsonData.pvArrays || []).map((pvArray, index) => (
+          <div className="gf-form" key={index}>
+            <InlineField label="Name" labelWidth={8}>
+              <Input aria-label="PV array name" onChange={this.onPVArrayNameChange(index)} value={pvArray.name} width={16} />
+            </InlineField>
+            <InlineField label="kWp" labelWidth={6}>
+              <Input
+                aria-label="PV array kWp"
+                onChange={this.onPVArrayNumberChange(index, 'kwp')}
+                value={pvArray.kwp ?? ''}
+                type="number"
+                min={0}
+                width={10}
+              />
+            </InlineField>
+            <InlineField label="Tilt" labelWidth={6}>
+              <Input
+                aria-label="PV array tilt"
+                onChange={this.onPVArrayNumberChange(index, 'tilt')}
+                value={pvArray.tilt ?? ''}
+                type="number"
+                min={0}
+                max={90}
+                width={8}
+              />
+            </InlineField>
+            <InlineField label="Azimuth" labelWidth={9} tooltip="Degrees clockwise from north, facing the equator by default">
+              <Input
+                aria-label="PV array azimuth"
+                onChange={this.onPVArrayNumberChange(index, 'azimuth')}
+                value={pvArray.azimuth ?? ''}
+                placeholder="180"
+                type="number"
+                width={8}
+              />
+            </InlineField>
+            <InlineField label="Tracker" labelWidth={9}>
+              <Select
+                aria-label="PV array tracker"
+                options={trackers}
+                value={pvArray.tracker || 'fixed'}
+                onChange={this.onPVArrayTrackerChange(index)}
+                width={24}
+              />
+            </InlineField>
+            <InlineField label="Temp. coeff." labelWidth={12} tooltip="Power change in %/°C">
+              <Input
+                aria-label="PV array temperature coefficient"
+                onChange={this.onPVArrayNumberChange(index, 'temperatureCoefficient')}
+                value={pvArray.temperatureCoefficient ?? ''}
+                placeholder="-0.4"
+                type="number"
+                step="0.01"
+                width={8}
+              />
+            </InlineField>
+            <InlineField label="Losses" labelWidth={8} tooltip="System losses in %">
+              <Input
+                aria-label="PV array losses"
+                onChange={this.onPVArrayNumberChange(index, 'losses')}
+                value={pvArray.losses ?? ''}
+                placeholder="14"
+                type="number"
+                min={0}
+                max={100}
+                width={8}
+              />
+            </InlineField>
+            <Button
+              aria-label="Remove PV array"
+              icon="trash-alt"
+              variant="secondary"
+              onClick={this.onRemovePVArray(index)}
+            />
+          </div>
+        ))}
+        <Button icon="plus" variant="secondary" onClick={this.onAddPVArray}>
+          Add PV array
+        </Button>
+        <h3 className="page-heading">Locations</h3>
+        {(jsonData.locations || []).map((location, index) => (
+          <div className="gf-form" key={index}>
+            <InlineField label="Name" labelWidth={8}>
+              <Input
+                aria-label="Location name"
+                onChange={this.onLocationTextChange(index, 'name')}
+                value={location.name || ''}
+                width={16}
+              />
+            </InlineField>
+            <InlineField label="Latitude" labelWidth={10}>
+              <Input
+                aria-label="Location latitude"
+                onChange={this.onLocationNumberChange(index, 'latitude')}
+                value={location.latitude ?? ''}
+                type="number"
+                min={-90}
+                max={90}
+                width={12}
+              />
+            </InlineField>
+            <InlineField label="Longitude" labelWidth={11}>
+              <Input
+                aria-label="Location longitude"
+                onChange={this.onLocationNumberChange(index, 'longitude')}
+                value={location.longitude ?? ''}
+                type="number"
+                min={-180}
+                max={180}
+                width={12}
+              />
+            </InlineField>
+            <InlineField label="Elevation" labelWidth={10} tooltip="Meters above sea level">
+              <Input
+                aria-label="Location elevation"
+                onChange={this.onLocationNumberChange(index, 'elevation')}
+                value={location.elevation ?? ''}
+                placeholder="Datasource"
+                type="number"
+                width={10}
+              />
+            </InlineField>
+            <InlineField label="Timezone" labelWidth={10} tooltip="IANA timezone, e.g. Europe/Berlin">
+              <Input
+                aria-label="Location timezone"
+                onChange={this.onLocationTextChange(index, 'timezone')}
+                value={location.timezone || ''}
+                placeholder="Query timezone"
+                width={20}
+              />
+            </InlineField>
+            <Button
+              aria-label="Remove location"
+              icon="trash-alt"
+              variant="secondary"
+              onClick={this.onRemoveLocation(index)}
+            />
+          </div>
+        ))}
+        <Button icon="plus" variant="secondary" onClick={this.onAddLocation}>
+          Add location
+        </Button>
       </div>
     );
   }
@@ -18,6 +18,11 @@ describe('QueryEditor', () => {
       datasource: {
         latitude: 50,
         longitude: 30,
+        instanceSettings: { jsonData: {} },
+        getCatalogue: jest.fn().mockResolvedValue([
+          { label: 'Sun altitude', value: 'sun_altitude' },
+          { label: 'Moon altitude', value: 'moon_altitude' },
+        ]),
       },
     };
   });
@@ -26,6 +31,16 @@ describe('QueryEditor', () => {
     expect(() => render(<QueryEditor {...props} />)).not.toThrow();
   });
 
+  it('should show why the catalogue failed to load', async () => {
+    props.datasource.getCatalogue = jest
+      .fn()
+      .mockRejectedValue(new Error('Failed to load metrics and annotations: Plugin unavailable'));
+    render(<QueryEditor {...props} />);
+
+    expect(await screen.findByText('Failed to load metrics and annotations: Plugin unavailable')).toBeInTheDocument();
+    expect(screen.getByLabelText('Metric')).toBeInTheDocument();
+  });
+
   it('should update query on change', async () => {
     const onChange = jest.fn();
     const onRunQuery = jest.fn();
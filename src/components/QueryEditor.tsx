@@ -1,21 +1,57 @@
-import React, { ChangeEvent } from 'react';
-import { InlineField, Input, Stack, MultiSelect } from '@grafana/ui';
+import React, { ChangeEvent, useEffect, useState } from 'react';
+import { Alert, Button, InlineField, InlineSwitch, Input, Stack, MultiSelect, Select } from '@grafana/ui';
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { DataSource } from '../datasource';
-import { SunAndMoonQuery, SunAndMoonDataSourceOptions } from '../types';
-import { sunAndMoonMetrics, sunAndMoonAnnotations } from 'metrics';
+import { QueryLocation, SunAndMoonQuery, SunAndMoonDataSourceOptions } from '../types';
 
 // Typdefinition für die Props
 type Props = QueryEditorProps<DataSource, SunAndMoonQuery, SunAndMoonDataSourceOptions>;
 
-// Zusammenführen der Metrik- und Annotationsoptionen
-const metrics = Object.keys({ ...sunAndMoonMetrics, ...sunAndMoonAnnotations }).map((key) => ({
-  label: sunAndMoonMetrics[key]?.title || sunAndMoonAnnotations[key]?.title,
-  value: key,
-  description: sunAndMoonMetrics[key]?.text || sunAndMoonAnnotations[key]?.text,
-})) as Array<SelectableValue<string>>;
+// Algorithmen für die Sonnenposition
+const engines: Array<SelectableValue<SunAndMoonQuery['engine']>> = [
+  { label: 'suncalc', value: 'suncalc', description: 'Fast, accurate to a fraction of a degree' },
+  { label: 'NREL SPA', value: 'spa', description: 'Solar Position Algorithm, accurate to ±0.0003°' },
+];
+
+// Modelle für die Einstrahlung bei klarem Himmel
+const clearSkyModels: Array<SelectableValue<SunAndMoonQuery['clearSkyModel']>> = [
+  { label: 'Ineichen-Perez', value: 'ineichen', description: 'Uses the Linke turbidity and the elevation' },
+  { label: 'Haurwitz', value: 'haurwitz', description: 'Depends on the sun altitude only' },
+];
+
+// Geometrien der geneigten Fläche
+const trackers: Array<SelectableValue<SunAndMoonQuery['tracker']>> = [
+  { label: 'Fixed', value: 'fixed', description: 'Fixed tilt and azimuth' },
+  { label: 'Single-axis tracker', value: 'singleAxis', description: 'Rotates around an axis with backtracking' },
+];
+
+// Richtungen, in denen die Sonne eine Höhe durchläuft
+const sunDirections: Array<SelectableValue<SunAndMoonQuery['sunDirection']>> = [
+  { label: 'Rising', value: 'rising', description: 'Sun climbs through the altitude in the morning' },
+  { label: 'Setting', value: 'setting', description: 'Sun sinks through the altitude in the evening' },
+];
+
+// Metriken, die eine geneigte Fläche brauchen
+const surfaceMetrics = ['surface_aoi', 'poa_irradiance', 'tracker_rotation'];
+
+export function QueryEditor({ datasource, query, onChange, onRunQuery }: Props) {
+  // Metrik- und Annotationsoptionen aus dem Katalog des Backends
+  const [metrics, setMetrics] = useState<Array<SelectableValue<string>>>([]);
+  const [catalogueError, setCatalogueError] = useState<string>();
+  useEffect(() => {
+    datasource
+      .getCatalogue()
+      .then((catalogue) => {
+        setMetrics(catalogue);
+        setCatalogueError(undefined);
+      })
+      .catch((error: Error) => {
+        // Ohne Katalog bleiben nur eigene Werte, der Fehler wird angezeigt
+        setMetrics([]);
+        setCatalogueError(error.message);
+      });
+  }, [datasource]);
 
-export function QueryEditor({ query, onChange, onRunQuery }: Props) {
   const onMetricChange = (selected: Array<SelectableValue<string>>) => {
     onChange({ ...query, target: selected.map((selection) => selection.value!) });
     onRunQuery(); // Führt die Abfrage aus
@@ -31,13 +67,179 @@ export function QueryEditor({ query, onChange, onRunQuery }: Props) {
     onRunQuery();
   };
 
-  const { target, latitude, longitude } = query;
+  const onTimezoneChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, timezone: event.target.value });
+    onRunQuery();
+  };
+
+  const onElevationChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, elevation: event.target.value });
+    onRunQuery();
+  };
+
+  const onPressureChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, pressure: event.target.value });
+    onRunQuery();
+  };
+
+  const onTemperatureChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, temperature: event.target.value });
+    onRunQuery();
+  };
+
+  const onLiveChange = (event: React.FormEvent<HTMLInputElement>) => {
+    onChange({ ...query, live: event.currentTarget.checked });
+    onRunQuery();
+  };
+
+  const onLiveIntervalChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseInt(event.target.value, 10);
+    onChange({ ...query, liveInterval: isNaN(value) ? undefined : value });
+    onRunQuery();
+  };
+
+  const onEngineChange = (selected: SelectableValue<SunAndMoonQuery['engine']>) => {
+    onChange({ ...query, engine: selected.value });
+    onRunQuery();
+  };
+
+  const onDeltaTChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({ ...query, deltaT: isNaN(value) ? undefined : value });
+    onRunQuery();
+  };
+
+  const onSupermoonThresholdChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({ ...query, supermoonThreshold: isNaN(value) ? undefined : value });
+    onRunQuery();
+  };
+
+  const onLinkeTurbidityChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, linkeTurbidity: event.target.value });
+    onRunQuery();
+  };
+
+  const onClearSkyModelChange = (selected: SelectableValue<SunAndMoonQuery['clearSkyModel']>) => {
+    onChange({ ...query, clearSkyModel: selected.value });
+    onRunQuery();
+  };
+
+  const onSurfaceTiltChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, surfaceTilt: event.target.value });
+    onRunQuery();
+  };
+
+  const onSurfaceAzimuthChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, surfaceAzimuth: event.target.value });
+    onRunQuery();
+  };
+
+  const onTrackerChange = (selected: SelectableValue<SunAndMoonQuery['tracker']>) => {
+    onChange({ ...query, tracker: selected.value });
+    onRunQuery();
+  };
+
+  const onMaxAngleChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({ ...query, maxAngle: isNaN(value) ? undefined : value });
+    onRunQuery();
+  };
+
+  const onGroundCoverageRatioChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({ ...query, groundCoverageRatio: isNaN(value) ? undefined : value });
+    onRunQuery();
+  };
+
+  const onSunAltitudeChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, sunAltitude: event.target.value });
+    onRunQuery();
+  };
+
+  const onSunDirectionChange = (selected: SelectableValue<SunAndMoonQuery['sunDirection']>) => {
+    onChange({ ...query, sunDirection: selected?.value });
+    onRunQuery();
+  };
+
+  const onRegisteredLocationChange = (selected: SelectableValue<string>) => {
+    onChange({ ...query, location: selected?.value });
+    onRunQuery();
+  };
+
+  // Benannte Standorte der Datenquelle
+  const registeredLocations: Array<SelectableValue<string>> = (
+    datasource.instanceSettings.jsonData.locations || []
+  ).map((registered) => ({ label: registered.name, value: registered.name }));
+
+  const setLocations = (locations: QueryLocation[]) => {
+    onChange({ ...query, locations: locations.length > 0 ? locations : undefined });
+    onRunQuery();
+  };
+
+  const onLocationChange =
+    (index: number, key: keyof QueryLocation) => (event: ChangeEvent<HTMLInputElement>) => {
+      const locations = [...(query.locations || [])];
+      locations[index] = { ...locations[index], [key]: event.target.value };
+      setLocations(locations);
+    };
+
+  const onAddLocation = () => {
+    const locations = query.locations || [];
+    setLocations([...locations, { name: `Site ${locations.length + 1}`, latitude: '', longitude: '' }]);
+  };
+
+  const onRemoveLocation = (index: number) => () => {
+    setLocations((query.locations || []).filter((_, i) => i !== index));
+  };
+
+  const onPVArrayChange = (selected: SelectableValue<string>) => {
+    onChange({ ...query, pvArray: selected?.value });
+    onRunQuery();
+  };
+
+  // PV-Modulfelder der Datenquelle
+  const pvArrays: Array<SelectableValue<string>> = (datasource.instanceSettings.jsonData.pvArrays || []).map(
+    (pvArray) => ({ label: pvArray.name, value: pvArray.name })
+  );
+
+  const {
+    target,
+    latitude,
+    longitude,
+    timezone,
+    elevation,
+    pressure,
+    temperature,
+    live,
+    liveInterval,
+    engine,
+    deltaT,
+    supermoonThreshold,
+    linkeTurbidity,
+    clearSkyModel,
+    surfaceTilt,
+    surfaceAzimuth,
+    tracker,
+    maxAngle,
+    groundCoverageRatio,
+    pvArray,
+    sunAltitude,
+    sunDirection,
+    location,
+    locations,
+  } = query;
 
   return (
     <Stack direction={'column'}>
       {' '}
       {/* Set gap to 10 for better spacing between rows */}
       {/* Metrik-Auswahl */}
+      {catalogueError && (
+        <Alert severity="error" title="Catalogue unavailable">
+          {catalogueError}
+        </Alert>
+      )}
       <InlineField label="Metric">
         <MultiSelect
           inputId="editor-metrics"
@@ -45,32 +247,320 @@ export function QueryEditor({ query, onChange, onRunQuery }: Props) {
           value={target}
           onChange={onMetricChange}
           placeholder="Select Metric"
+          allowCustomValue // Annotationen mit Versatz, z.B. sunset-30m
+        />
+      </InlineField>
+      {/* Location */}
+      <InlineField
+        label="Location"
+        labelWidth={20}
+        tooltip={`Named location of the datasource or a city like "Zurich" or "Berlin, DE" from the built-in offline list, replacing latitude and longitude`}
+      >
+        <Select
+          inputId="location"
+          options={registeredLocations}
+          value={location ? { label: location, value: location } : null}
+          onChange={onRegisteredLocationChange}
+          placeholder="Coordinates below"
+          isClearable
+          allowCustomValue // Städte aus dem eingebauten Ortsverzeichnis
+          width={32}
         />
       </InlineField>
       {/* Latitude */}
-      <InlineField label="Override Latitude" labelWidth={20}>
+      <InlineField
+        label="Override Latitude"
+        labelWidth={20}
+        tooltip={`Decimal degrees or degrees, minutes and seconds like 52°31'N. With an empty longitude also the whole position, as Maidenhead locator like JO62qm, geohash like u33dc0 or pair like 52°31'N 13°24'E.`}
+      >
+        <Input id="latitude" onChange={onLatitudeChange} value={latitude || ''} placeholder="Enter Latitude" width={32} />
+      </InlineField>
+      {/* Longitude */}
+      <InlineField label="Override Longitude" labelWidth={20} tooltip={`Decimal degrees or degrees, minutes and seconds like 13°24'E`}>
+        <Input id="longitude" onChange={onLongitudeChange} value={longitude || ''} placeholder="Enter Longitude" width={32} />
+      </InlineField>
+      {/* Locations */}
+      {(locations || []).map((site, index) => (
+        <Stack key={index} direction={'row'}>
+          <InlineField label="Site" labelWidth={20}>
+            <Input aria-label="Site name" onChange={onLocationChange(index, 'name')} value={site.name} width={16} />
+          </InlineField>
+          <InlineField label="Lat" labelWidth={6}>
+            <Input
+              aria-label="Site latitude"
+              onChange={onLocationChange(index, 'latitude')}
+              value={site.latitude || ''}
+              placeholder="Registered"
+              width={12}
+            />
+          </InlineField>
+          <InlineField label="Lon" labelWidth={6}>
+            <Input
+              aria-label="Site longitude"
+              onChange={onLocationChange(index, 'longitude')}
+              value={site.longitude || ''}
+              placeholder="Registered"
+              width={12}
+            />
+          </InlineField>
+          <InlineField label="Timezone" labelWidth={10}>
+            <Input
+              aria-label="Site timezone"
+              onChange={onLocationChange(index, 'timezone')}
+              value={site.timezone || ''}
+              placeholder="Query timezone"
+              width={20}
+            />
+          </InlineField>
+          <Button aria-label="Remove site" icon="trash-alt" variant="secondary" onClick={onRemoveLocation(index)} />
+        </Stack>
+      ))}
+      <InlineField label="Sites" labelWidth={20} tooltip="Named locations replacing latitude and longitude, one series per site. Sites without coordinates are the locations of the datasource or the cities of the built-in list with that name.">
+        <Button icon="plus" variant="secondary" onClick={onAddLocation}>
+          Add site
+        </Button>
+      </InlineField>
+      {/* Timezone */}
+      <InlineField label="Override Timezone" labelWidth={20} tooltip="IANA timezone, e.g. Europe/Berlin">
         <Input
-          id="latitude"
-          onChange={onLatitudeChange}
-          value={latitude || ''}
-          placeholder="Enter Latitude"
+          id="timezone"
+          onChange={onTimezoneChange}
+          value={timezone || ''}
+          placeholder="Dashboard timezone"
+          width={32}
+        />
+      </InlineField>
+      {/* Elevation */}
+      <InlineField label="Override Elevation" labelWidth={20} tooltip="Meters above sea level, lowers the horizon">
+        <Input
+          id="elevation"
+          onChange={onElevationChange}
+          value={elevation || ''}
+          placeholder="Datasource elevation"
           width={32}
           type="number"
-          step="0.1"
         />
       </InlineField>
-      {/* Longitude */}
-      <InlineField label="Override Longitude" labelWidth={20}>
+      {/* Atmosphere */}
+      <InlineField label="Override Pressure" labelWidth={20} tooltip="Air pressure in mbar for the refraction">
         <Input
-          id="longitude"
-          onChange={onLongitudeChange}
-          value={longitude || ''}
-          placeholder="Enter Longitude"
+          id="pressure"
+          onChange={onPressureChange}
+          value={pressure || ''}
+          placeholder="Datasource pressure"
           width={32}
           type="number"
-          step="0.1"
         />
       </InlineField>
+      <InlineField label="Override Temperature" labelWidth={20} tooltip="Air temperature in °C for the refraction">
+        <Input
+          id="temperature"
+          onChange={onTemperatureChange}
+          value={temperature || ''}
+          placeholder="Datasource temperature"
+          width={32}
+          type="number"
+        />
+      </InlineField>
+      {/* Engine */}
+      <InlineField label="Sun position" labelWidth={20}>
+        <Select inputId="engine" options={engines} value={engine || 'suncalc'} onChange={onEngineChange} width={32} />
+      </InlineField>
+      {engine === 'spa' && (
+        <InlineField label="ΔT" labelWidth={20} tooltip="Difference between terrestrial and universal time in seconds">
+          <Input
+            id="deltaT"
+            onChange={onDeltaTChange}
+            value={deltaT ?? ''}
+            placeholder="Estimated"
+            width={32}
+            type="number"
+          />
+        </InlineField>
+      )}
+      {/* Supermoon */}
+      {(target?.includes('supermoon') || target?.includes('micromoon')) && (
+        <InlineField
+          label="Supermoon threshold"
+          labelWidth={20}
+          tooltip="Percent of the way from apogee to perigee a full or new moon needs to be a supermoon"
+        >
+          <Input
+            id="supermoonThreshold"
+            onChange={onSupermoonThresholdChange}
+            value={supermoonThreshold ?? ''}
+            placeholder="90"
+            width={32}
+            type="number"
+            min={50}
+            max={100}
+          />
+        </InlineField>
+      )}
+      {/* Clear sky */}
+      {target?.some((t) => t.startsWith('clear_sky_')) && (
+        <>
+          <InlineField label="Clear-sky model" labelWidth={20}>
+            <Select
+              inputId="clearSkyModel"
+              options={clearSkyModels}
+              value={clearSkyModel || 'ineichen'}
+              onChange={onClearSkyModelChange}
+              width={32}
+            />
+          </InlineField>
+          {clearSkyModel !== 'haurwitz' && (
+            <InlineField
+              label="Linke turbidity"
+              labelWidth={20}
+              tooltip="Haze of the clear sky, 2 for very clear and 6 for hazy air"
+            >
+              <Input
+                id="linkeTurbidity"
+                onChange={onLinkeTurbidityChange}
+                value={linkeTurbidity || ''}
+                placeholder="Datasource turbidity"
+                width={32}
+                type="number"
+              />
+            </InlineField>
+          )}
+        </>
+      )}
+      {/* Surface */}
+      {target?.some((t) => surfaceMetrics.includes(t)) && (
+        <>
+          <InlineField label="Surface" labelWidth={20}>
+            <Select
+              inputId="tracker"
+              options={trackers}
+              value={tracker || 'fixed'}
+              onChange={onTrackerChange}
+              width={32}
+            />
+          </InlineField>
+          <InlineField
+            label={tracker === 'singleAxis' ? 'Axis tilt' : 'Surface tilt'}
+            labelWidth={20}
+            tooltip="Degrees from horizontal"
+          >
+            <Input
+              id="surfaceTilt"
+              onChange={onSurfaceTiltChange}
+              value={surfaceTilt || ''}
+              placeholder="0"
+              width={32}
+              type="number"
+            />
+          </InlineField>
+          <InlineField
+            label={tracker === 'singleAxis' ? 'Axis azimuth' : 'Surface azimuth'}
+            labelWidth={20}
+            tooltip="Degrees clockwise from north, facing the equator by default"
+          >
+            <Input
+              id="surfaceAzimuth"
+              onChange={onSurfaceAzimuthChange}
+              value={surfaceAzimuth || ''}
+              placeholder="180"
+              width={32}
+              type="number"
+            />
+          </InlineField>
+          {tracker === 'singleAxis' && (
+            <>
+              <InlineField label="Max angle" labelWidth={20} tooltip="Rotation limit of the tracker in degrees">
+                <Input
+                  id="maxAngle"
+                  onChange={onMaxAngleChange}
+                  value={maxAngle ?? ''}
+                  placeholder="60"
+                  width={32}
+                  type="number"
+                  min={0}
+                  max={90}
+                />
+              </InlineField>
+              <InlineField
+                label="Ground coverage"
+                labelWidth={20}
+                tooltip="Module width over row spacing for backtracking, 0 disables backtracking"
+              >
+                <Input
+                  id="groundCoverageRatio"
+                  onChange={onGroundCoverageRatioChange}
+                  value={groundCoverageRatio ?? ''}
+                  placeholder="0.35"
+                  width={32}
+                  type="number"
+                  min={0}
+                  max={1}
+                  step="0.05"
+                />
+              </InlineField>
+            </>
+          )}
+        </>
+      )}
+      {/* PV */}
+      {target?.some((t) => t.startsWith('pv_')) && (
+        <InlineField label="PV array" labelWidth={20} tooltip="Sum of all PV arrays of the datasource if empty">
+          <Select
+            inputId="pvArray"
+            options={pvArrays}
+            value={pvArray}
+            onChange={onPVArrayChange}
+            placeholder="All arrays"
+            isClearable
+            width={32}
+          />
+        </InlineField>
+      )}
+      {/* Sun altitude crossing */}
+      {target?.includes('sunAltitudeCross') && (
+        <>
+          <InlineField label="Sun altitude" labelWidth={20} tooltip="Degrees above the horizon, negative below it">
+            <Input
+              id="sunAltitude"
+              onChange={onSunAltitudeChange}
+              value={sunAltitude || ''}
+              placeholder="e.g. -4 or 10"
+              width={32}
+              type="number"
+              min={-90}
+              max={90}
+            />
+          </InlineField>
+          <InlineField label="Sun direction" labelWidth={20}>
+            <Select
+              inputId="sunDirection"
+              options={sunDirections}
+              value={sunDirection}
+              onChange={onSunDirectionChange}
+              placeholder="Rising and setting"
+              isClearable
+              width={32}
+            />
+          </InlineField>
+        </>
+      )}
+      {/* Live */}
+      <InlineField label="Live position" labelWidth={20} tooltip="Stream the current sun and moon position">
+        <InlineSwitch id="live" value={live || false} onChange={onLiveChange} />
+      </InlineField>
+      {live && (
+        <InlineField label="Live interval" labelWidth={20} tooltip="Seconds between updates">
+          <Input
+            id="liveInterval"
+            onChange={onLiveIntervalChange}
+            value={liveInterval || ''}
+            placeholder="10"
+            width={32}
+            type="number"
+            min={1}
+          />
+        </InlineField>
+      )}
     </Stack>
   );
 }
@@ -1,7 +1,23 @@
-import { DataSourceInstanceSettings, CoreApp, ScopedVars } from '@grafana/data';
+import {
+  DataSourceInstanceSettings,
+  CoreApp,
+  ScopedVars,
+  DataQueryRequest,
+  DataQueryResponse,
+  MetricFindValue,
+  SelectableValue,
+} from '@grafana/data';
 import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
+import { Observable } from 'rxjs';
 
-import { SunAndMoonQuery, SunAndMoonDataSourceOptions, DEFAULT_QUERY } from './types';
+import {
+  SunAndMoonQuery,
+  SunAndMoonDataSourceOptions,
+  DEFAULT_QUERY,
+  MetricDefinition,
+  AnnotationDefinition,
+  Location,
+} from './types';
 
 export class DataSource extends DataSourceWithBackend<SunAndMoonQuery, SunAndMoonDataSourceOptions> {
   // Define default latitude and longitude if not provided
@@ -25,12 +41,43 @@ export class DataSource extends DataSourceWithBackend<SunAndMoonQuery, SunAndMoo
     };
   }
 
+  // Pass the dashboard timezone to the backend, resolving "browser" to an IANA name
+  query(request: DataQueryRequest<SunAndMoonQuery>): Observable<DataQueryResponse> {
+    const dashboardTimezone =
+      !request.timezone || request.timezone === 'browser'
+        ? Intl.DateTimeFormat().resolvedOptions().timeZone
+        : request.timezone;
+    return super.query({
+      ...request,
+      targets: request.targets.map((target) => ({ ...target, dashboardTimezone })),
+    });
+  }
+
   // Apply template variables
   applyTemplateVariables(query: SunAndMoonQuery, scopedVars: ScopedVars) {
     return {
       ...query,
       latitude: getTemplateSrv().replace(query.latitude?.toString() || this.defaultLatitude.toString(), scopedVars),
-      longitude: getTemplateSrv().replace(query.longitude?.toString() || this.defaultLongitude.toString(), scopedVars),
+      // Without longitude the latitude can hold the whole position, e.g. a Maidenhead locator
+      longitude:
+        query.latitude && !query.longitude
+          ? ''
+          : getTemplateSrv().replace(query.longitude?.toString() || this.defaultLongitude.toString(), scopedVars),
+      timezone: query.timezone ? getTemplateSrv().replace(query.timezone, scopedVars) : undefined,
+      elevation: query.elevation ? getTemplateSrv().replace(query.elevation, scopedVars) : undefined,
+      pressure: query.pressure ? getTemplateSrv().replace(query.pressure, scopedVars) : undefined,
+      temperature: query.temperature ? getTemplateSrv().replace(query.temperature, scopedVars) : undefined,
+      linkeTurbidity: query.linkeTurbidity ? getTemplateSrv().replace(query.linkeTurbidity, scopedVars) : undefined,
+      surfaceTilt: query.surfaceTilt ? getTemplateSrv().replace(query.surfaceTilt, scopedVars) : undefined,
+      surfaceAzimuth: query.surfaceAzimuth ? getTemplateSrv().replace(query.surfaceAzimuth, scopedVars) : undefined,
+      sunAltitude: query.sunAltitude ? getTemplateSrv().replace(query.sunAltitude, scopedVars) : undefined,
+      locations: query.locations?.map((location) => ({
+        ...location,
+        latitude: location.latitude ? getTemplateSrv().replace(location.latitude, scopedVars) : undefined,
+        longitude: location.longitude ? getTemplateSrv().replace(location.longitude, scopedVars) : undefined,
+        timezone: location.timezone ? getTemplateSrv().replace(location.timezone, scopedVars) : undefined,
+      })),
+      location: query.location ? getTemplateSrv().replace(query.location, scopedVars) : undefined,
       target: query.target?.map((t) => getTemplateSrv().replace(t, scopedVars)),
     };
   }
@@ -40,6 +87,44 @@ export class DataSource extends DataSourceWithBackend<SunAndMoonQuery, SunAndMoo
     return Array.isArray(query.target) && query.target.length > 0;
   }
 
+  // Load the metric catalogue from the backend
+  getMetrics(): Promise<MetricDefinition[]> {
+    return this.getResource('metrics');
+  }
+
+  // Load the annotation catalogue from the backend
+  getAnnotations(): Promise<AnnotationDefinition[]> {
+    return this.getResource('annotations');
+  }
+
+  // Load the named locations of the datasource from the backend
+  getLocations(): Promise<Location[]> {
+    return this.getResource('locations');
+  }
+
+  // Metrics and annotations as options for the query editor, fails with the message of the backend
+  async getCatalogue(): Promise<Array<SelectableValue<string>>> {
+    try {
+      const [metrics, annotations] = await Promise.all([this.getMetrics(), this.getAnnotations()]);
+      return [...metrics, ...annotations].map(({ value, title, text }) => ({ label: title, value, description: text }));
+    } catch (error: any) {
+      const message = error?.data?.message || error?.statusText || error?.message || String(error);
+      throw new Error(`Failed to load metrics and annotations: ${message}`);
+    }
+  }
+
+  // Template variable query: "metrics", "annotations" or empty for both, "locations" for the named locations
+  async metricFindQuery(query: string): Promise<MetricFindValue[]> {
+    const kind = getTemplateSrv().replace(query).trim();
+    if (kind === 'locations') {
+      const locations = await this.getLocations();
+      return locations.map(({ name }) => ({ text: name, value: name }));
+    }
+    const metrics = kind === 'annotations' ? [] : await this.getMetrics();
+    const annotations = kind === 'metrics' ? [] : await this.getAnnotations();
+    return [...metrics, ...annotations].map(({ value, title }) => ({ text: title, value }));
+  }
+
   // Handle Annotation Queries
   annotations = {
     
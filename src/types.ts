@@ -6,6 +6,37 @@ export interface SunAndMoonQuery extends DataQuery {
   target?: string[]; // Array von Metriken, die abgefragt werden
   latitude?: string; // Optional: Breitenangabe als String (für Eingaben im Editor)
   longitude?: string; // Optional: Längenangabe als String (für Eingaben im Editor)
+  timezone?: string; // Optional: IANA Zeitzone, überschreibt Datenquelle und Dashboard
+  elevation?: string; // Optional: Höhe des Beobachters in Metern, überschreibt die Datenquelle
+  pressure?: string; // Optional: Luftdruck in mbar, überschreibt die Datenquelle
+  temperature?: string; // Optional: Lufttemperatur in °C, überschreibt die Datenquelle
+  dashboardTimezone?: string; // Zeitzone des Dashboards, wird vom Frontend gesetzt
+  live?: boolean; // Aktuelle Position von Sonne und Mond live streamen
+  liveInterval?: number; // Optional: Intervall des Streams in Sekunden
+  engine?: 'suncalc' | 'spa'; // Optional: Algorithmus für die Sonnenposition
+  deltaT?: number; // Optional: TT - UT in Sekunden für den SPA-Algorithmus
+  supermoonThreshold?: number; // Optional: Prozent des Wegs vom Apogäum zum Perigäum für einen Supermond
+  linkeTurbidity?: string; // Optional: Linke-Trübung für die Einstrahlung bei klarem Himmel, überschreibt die Datenquelle
+  clearSkyModel?: 'ineichen' | 'haurwitz'; // Optional: Modell für die Einstrahlung bei klarem Himmel
+  surfaceTilt?: string; // Optional: Neigung der Fläche bzw. der Trackerachse in Grad
+  surfaceAzimuth?: string; // Optional: Ausrichtung der Fläche bzw. der Trackerachse in Grad von Norden
+  tracker?: 'fixed' | 'singleAxis'; // Optional: Feste Fläche oder einachsiger Tracker
+  maxAngle?: number; // Optional: Maximale Drehung des Trackers in Grad
+  groundCoverageRatio?: number; // Optional: Modulbreite durch Reihenabstand für das Backtracking
+  pvArray?: string; // Optional: Name eines einzelnen PV-Modulfelds, sonst alle
+  sunAltitude?: string; // Optional: Sonnenhöhe in Grad für die Annotation sunAltitudeCross
+  sunDirection?: 'rising' | 'setting'; // Optional: Nur aufsteigende oder absteigende Durchgänge, sonst beide
+  location?: string; // Optional: Name eines Standorts der Datenquelle oder einer Stadt wie "Berlin, DE" statt Latitude/Longitude
+  locations?: QueryLocation[]; // Optional: Benannte Standorte statt Latitude/Longitude, ein Frame pro Standort
+}
+
+// Benannter Standort einer Abfrage, sein Name wird zum Label "site". Ohne
+// Koordinaten ist es der Standort der Datenquelle oder die Stadt mit diesem Namen.
+export interface QueryLocation {
+  name: string;
+  latitude?: string;
+  longitude?: string;
+  timezone?: string; // Optional: IANA Zeitzone, sonst die der Abfrage
 }
 
 // Standardwerte für Abfragen (Metriken und ggf. Default-Latitude/Longitude)
@@ -13,6 +44,26 @@ export const DEFAULT_QUERY: Partial<SunAndMoonQuery> = {
   target: ['moon_illumination'], // Standard-Metrik für die Abfrage
 };
 
+// Metrik aus dem Katalog des Backends (Resource "metrics")
+export interface MetricDefinition {
+  value: string;
+  title: string;
+  text: string;
+  unit: string;
+  decimals: number;
+  min: number;
+  states?: string[];
+}
+
+// Annotation aus dem Katalog des Backends (Resource "annotations")
+export interface AnnotationDefinition {
+  value: string;
+  title: string;
+  text: string;
+  tags: string[];
+  region: boolean;
+}
+
 // Typ für einen einzelnen Datenpunkt (Zeit und Wert)
 export interface DataPoint {
   time: number; // Zeitstempel als Zahl (Unix-Zeit)
@@ -31,4 +82,31 @@ export interface DataSourceResponse {
 export interface SunAndMoonDataSourceOptions extends DataSourceJsonData {
   latitude?: number; // Optional: Breitenangabe (Wird als Zahl gespeichert)
   longitude?: number; // Optional: Längenangabe (Wird als Zahl gespeichert)
+  timezone?: string; // Optional: IANA Zeitzone für Mittag, Mitternacht und Tagesgrenzen
+  elevation?: number; // Optional: Höhe über dem Meeresspiegel in Metern (Horizontabsenkung)
+  pressure?: number; // Optional: Luftdruck in mbar für die Refraktion, Standard 1013.25
+  temperature?: number; // Optional: Lufttemperatur in °C für die Refraktion, Standard 15
+  linkeTurbidity?: number; // Optional: Linke-Trübung für die Einstrahlung bei klarem Himmel, Standard 3
+  pvArrays?: PVArray[]; // Optional: PV-Modulfelder für die erwartete Leistung
+  locations?: Location[]; // Optional: Benannte Standorte, auf die sich Abfragen beziehen
+}
+
+// Benannter Standort einer Datenquelle
+export interface Location {
+  name: string;
+  latitude: number;
+  longitude: number;
+  elevation?: number; // Optional: Höhe über dem Meeresspiegel in Metern, sonst die der Datenquelle
+  timezone?: string; // Optional: IANA Zeitzone, sonst die der Abfrage
+}
+
+// PV-Modulfeld einer Datenquelle
+export interface PVArray {
+  name: string;
+  kwp: number; // Nennleistung in kWp
+  tilt: number; // Neigung in Grad, bei Trackern die der Achse
+  azimuth?: number; // Optional: Ausrichtung in Grad von Norden, Standard zum Äquator
+  tracker?: 'fixed' | 'singleAxis';
+  temperatureCoefficient?: number; // Optional: Leistungsänderung in %/°C, Standard -0.4
+  losses?: number; // Optional: Systemverluste in %, Standard 14
 }